@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"chessgame/game"
+)
+
+const (
+	snapshotName = "autosave.json"    // Full session, replaced atomically after every change
+	journalName  = "autosave.journal" // Append-only log of moves since the game started
+)
+
+// autosaver keeps the current game on disk so it survives the window closing.
+// Every change is appended to a journal first and then written as a session
+// snapshot with write-then-rename. If the snapshot is stale or unreadable, the
+// journal still has every completed move; a half-written last line is ignored.
+type autosaver struct {
+	dir     string
+	journal *os.File
+}
+
+// stateDir returns the per-user directory for saved state
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "chessgame"), nil
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "state", "chessgame"), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "chessgame"), nil
+}
+
+// newAutosaver creates the state directory if needed
+func newAutosaver() (*autosaver, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &autosaver{dir: dir}, nil
+}
+
+// writeFileAtomic replaces a file so readers see either the old or the new
+// contents, never a partial write
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// start rewrites the journal for a game, including the moves already played,
+// and saves a snapshot
+func (a *autosaver) start(board *game.Game, settings map[string]string) error {
+	a.close()
+
+	start := board.InitialFEN
+	if start == "" {
+		start = game.StartFEN
+	}
+	lines := []string{"start " + start}
+	for _, move := range board.Moves() {
+		lines = append(lines, "move "+move.UCI())
+	}
+	path := filepath.Join(a.dir, journalName)
+	if err := writeFileAtomic(path, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return err
+	}
+
+	journal, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	a.journal = journal
+	return a.snapshot(board, settings)
+}
+
+// record appends an entry to the journal and saves a snapshot
+func (a *autosaver) record(entry string, board *game.Game, settings map[string]string) error {
+	if a.journal == nil {
+		return a.start(board, settings)
+	}
+	if _, err := a.journal.WriteString(entry + "\n"); err != nil {
+		return err
+	}
+	if err := a.journal.Sync(); err != nil {
+		return err
+	}
+	return a.snapshot(board, settings)
+}
+
+// snapshot atomically writes the whole session
+func (a *autosaver) snapshot(board *game.Game, settings map[string]string) error {
+	data, err := board.SaveSession(settings)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(a.dir, snapshotName), data)
+}
+
+// load restores the autosaved game. The snapshot is used when it agrees with
+// the journal; otherwise the game is rebuilt from the journal, keeping the
+// snapshot's tags and settings if it could be read.
+func (a *autosaver) load() (*game.Game, map[string]string, error) {
+	var snapshot *game.Game
+	var settings map[string]string
+	data, snapshotErr := os.ReadFile(filepath.Join(a.dir, snapshotName))
+	if snapshotErr == nil {
+		snapshot, settings, snapshotErr = game.LoadSession(data)
+	}
+
+	replayed, journalErr := a.replayJournal()
+	switch {
+	case journalErr != nil && snapshotErr != nil:
+		return nil, nil, fmt.Errorf("no usable autosave: %v; %v", snapshotErr, journalErr)
+	case journalErr != nil:
+		return snapshot, settings, nil
+	case snapshot != nil && len(snapshot.Moves()) == len(replayed.Moves()) && snapshot.FEN() == replayed.FEN():
+		return snapshot, settings, nil
+	}
+
+	if snapshot != nil {
+		replayed.Tags = snapshot.Tags
+	}
+	return replayed, settings, nil
+}
+
+// replayJournal rebuilds the game recorded in the journal, stopping at the
+// first incomplete or invalid entry
+func (a *autosaver) replayJournal() (*game.Game, error) {
+	data, err := os.ReadFile(filepath.Join(a.dir, journalName))
+	if err != nil {
+		return nil, err
+	}
+
+	var board *game.Game
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	complete := strings.Count(string(data), "\n") // Lines that were fully written
+	for line := 0; line < complete && scanner.Scan(); line++ {
+		entry, arg, _ := strings.Cut(scanner.Text(), " ")
+		if board == nil {
+			if entry != "start" {
+				return nil, fmt.Errorf("journal does not begin with a start position")
+			}
+			if board, err = game.ParseFEN(arg); err != nil {
+				return nil, fmt.Errorf("journal start position: %v", err)
+			}
+			continue
+		}
+
+		switch entry {
+		case "move":
+			err = board.ApplyUCIMoves([]string{arg})
+		case "undo":
+			if !board.Undo() {
+				err = fmt.Errorf("nothing to undo")
+			}
+		case "redo":
+			if !board.Redo() {
+				err = fmt.Errorf("nothing to redo")
+			}
+		default:
+			err = fmt.Errorf("unknown entry %q", entry)
+		}
+		if err != nil {
+			break // Keep the moves recovered so far
+		}
+	}
+
+	if board == nil {
+		return nil, fmt.Errorf("journal is empty")
+	}
+	return board, nil
+}
+
+// close releases the journal file
+func (a *autosaver) close() {
+	if a.journal != nil {
+		a.journal.Close()
+		a.journal = nil
+	}
+}
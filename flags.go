@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"chessgame/game"
+)
+
+// Game modes selectable with -mode
+const (
+	modeHotSeat  = "hotseat"  // Two players take turns at the same board
+	modeComputer = "computer" // The player at the bottom plays against the computer
+	modeAnalysis = "analysis" // Both sides move freely, the engine suggests a move and nothing is autosaved
+)
+
+// options holds the command-line settings
+type options struct {
+	fen    string // Starting position, empty for the standard start
+	pgn    string // PGN file whose first game to load
+	ply    int    // Number of PGN moves to replay, -1 for all of them
+	side   string // Color shown at the bottom of the board, "white" or "black"
+	width  int    // Window size in pixels
+	height int
+	mode   string
+}
+
+// parseOptions parses and validates the command-line flags. Problems are
+// reported along with the usage text before the error is returned.
+func parseOptions(args []string) (*options, error) {
+	opts := &options{}
+	var size string
+
+	fs := flag.NewFlagSet("chessgame", flag.ContinueOnError)
+	fs.StringVar(&opts.fen, "fen", "", "start from the position in `FEN`")
+	fs.StringVar(&opts.pgn, "pgn", "", "start from the first game in PGN `file`")
+	fs.IntVar(&opts.ply, "ply", -1, "with -pgn, replay only the first `N` plies; the rest can be redone with Ctrl+Y")
+	fs.StringVar(&opts.side, "side", "white", "`color` at the bottom of the board, and the side you play against the computer (white or black)")
+	fs.StringVar(&size, "size", fmt.Sprintf("%dx%d", screenWidth, screenHeight), "window size as `WIDTHxHEIGHT`")
+	fs.StringVar(&opts.mode, "mode", modeHotSeat, "game `mode`: hotseat, computer or analysis")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: chessgame [flags]\n       chessgame perft <fen> <depth>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Report a problem the same way the flag package does
+	fail := func(format string, a ...any) (*options, error) {
+		err := fmt.Errorf(format, a...)
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return fail("unexpected argument %q", fs.Arg(0))
+	}
+	if opts.fen != "" && opts.pgn != "" {
+		return fail("-fen and -pgn cannot be used together")
+	}
+	if opts.ply != -1 {
+		if opts.pgn == "" {
+			return fail("-ply requires -pgn")
+		}
+		if opts.ply < 0 {
+			return fail("invalid value %d for flag -ply: must not be negative", opts.ply)
+		}
+	}
+	if opts.side != "white" && opts.side != "black" {
+		return fail("invalid value %q for flag -side: must be white or black", opts.side)
+	}
+	switch opts.mode {
+	case modeHotSeat, modeComputer, modeAnalysis:
+	default:
+		return fail("invalid value %q for flag -mode: must be hotseat, computer or analysis", opts.mode)
+	}
+
+	var err error
+	if opts.width, opts.height, err = parseSize(size); err != nil {
+		return fail("invalid value %q for flag -size: %v", size, err)
+	}
+
+	return opts, nil
+}
+
+// parseSize parses a window size such as "800x600"
+func parseSize(s string) (int, int, error) {
+	w, h, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, errors.New("expected WIDTHxHEIGHT")
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad width %q", w)
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad height %q", h)
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, errors.New("width and height must be positive")
+	}
+	return width, height, nil
+}
+
+// startingBoard sets up the game the flags ask for, or returns nil if they
+// don't name a starting position
+func (o *options) startingBoard() (*game.Game, error) {
+	switch {
+	case o.fen != "":
+		return game.ParseFEN(o.fen)
+	case o.pgn != "":
+		return loadPGN(o.pgn, o.ply)
+	}
+	return nil, nil
+}
+
+// loadPGN replays the first game in a PGN file. A non-negative ply stops after
+// that many moves, leaving the rest on the redo stack.
+func loadPGN(path string, ply int) (*game.Game, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pg, err := game.NewPGNReader(f).Next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: no games found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	board, err := pg.Game()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if ply >= 0 {
+		if moves := len(board.Moves()); ply > moves {
+			return nil, fmt.Errorf("%s: -ply %d is past the end of the game, which has %d plies", path, ply, moves)
+		}
+		for len(board.Moves()) > ply {
+			board.Undo()
+		}
+	}
+	return board, nil
+}
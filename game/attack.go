@@ -0,0 +1,133 @@
+package game
+
+// IsSquareAttacked checks if any piece of the given color attacks the square.
+// A square counts as attacked even if the attacker is pinned, and en passant
+// captures are not attacks.
+func IsSquareAttacked(board [8][8]int, square Position, byColor int) bool {
+	return newBitPosition(board, nil).isAttacked(squareIndex(square), byColor)
+}
+
+// AttackersOf returns the squares of the given color's pieces that attack the square
+func AttackersOf(board [8][8]int, square Position, byColor int) []Position {
+	p := newBitPosition(board, nil)
+	attackers := p.attackersOf(squareIndex(square), byColor, p.occupied)
+
+	squares := make([]Position, 0, attackers.Count())
+	for attackers != 0 {
+		squares = append(squares, squarePosition(attackers.popSquare()))
+	}
+	return squares
+}
+
+// Threat is a piece of the side to move that the opponent attacks
+type Threat struct {
+	Square  Position
+	Hanging bool // The opponent comes out ahead capturing it, by static exchange evaluation
+}
+
+// Threats returns the side to move's pieces that the opponent attacks. Each
+// exchange is judged starting with the opponent's least valuable attacker,
+// and a pawn capturing onto the last rank is taken to promote to a queen.
+func (g *Game) Threats() []Threat {
+	p := newBitPosition(g.Board, g)
+	color := p.turn
+
+	var threats []Threat
+	for pieces := p.colors[colorIndex(color)]; pieces != 0; {
+		sq := pieces.popSquare()
+		attackers := p.attackersOf(sq, -color, p.occupied)
+		if attackers == 0 {
+			continue
+		}
+		first, piece := p.leastValuableAttacker(attackers, -color)
+		move := p.newMove(first, sq)
+		if piece == Pawn && (sq < 8 || sq >= 56) {
+			move.Promotion = Queen
+			move.Flags |= FlagPromotion
+		}
+		threats = append(threats, Threat{
+			Square:  squarePosition(sq),
+			Hanging: p.staticExchange(move) > 0,
+		})
+	}
+	return threats
+}
+
+// kingExchangeValue is the king's value in exchanges. It is high enough that
+// capturing with the king onto a defended square never pays.
+const kingExchangeValue = 20000
+
+// exchangeValue returns a piece type's value in exchanges
+func exchangeValue(piece int) int {
+	if piece == King {
+		return kingExchangeValue
+	}
+	return pieceValues[piece]
+}
+
+// StaticExchange estimates the material the side making a capture wins, in
+// centipawns, once both sides have recaptured on the target square with
+// their least valuable attackers for as long as it pays. Pieces behind the
+// capturers join in as the line opens. A capture that promotes counts the
+// promotion, but recaptures are taken not to promote, and pins are ignored.
+// A negative result means the capture loses material.
+func (g *Game) StaticExchange(move Move) int {
+	return newBitPosition(g.Board, g).staticExchange(move)
+}
+
+// staticExchange plays out the capture sequence on the move's target square
+// with the swap algorithm
+func (p *bitPosition) staticExchange(move Move) int {
+	from, to := squareIndex(move.From), squareIndex(move.To)
+	captured := captureSquare(move)
+	color := sign(int(p.squares[from]))
+
+	// gain[d] is the material balance for the side capturing at depth d,
+	// if the piece it captures with is not taken back
+	var gain [32]int
+	gain[0] = exchangeValue(abs(int(p.squares[captured])))
+	capturer := abs(int(p.squares[from]))
+	if move.IsPromotion() {
+		// The promoted piece is what the opponent can take back
+		gain[0] += pieceValues[move.Promotion] - pieceValues[Pawn]
+		capturer = move.Promotion
+	}
+	occupied := p.occupied &^ squareBit(from) &^ squareBit(captured)
+
+	d := 0
+	for {
+		d++
+		color = -color
+		gain[d] = exchangeValue(capturer) - gain[d-1]
+
+		// Recapture with the least valuable attacker, which may be one that
+		// the previous capture uncovered
+		attackers := p.attackersOf(to, color, occupied)
+		if attackers == 0 {
+			break
+		}
+		var sq int
+		sq, capturer = p.leastValuableAttacker(attackers, color)
+		occupied &^= squareBit(sq)
+		if d == len(gain)-1 {
+			break
+		}
+	}
+
+	// Each side stops capturing when going on would leave it worse off
+	for d--; d > 0; d-- {
+		gain[d-1] = -max(-gain[d-1], gain[d])
+	}
+	return gain[0]
+}
+
+// leastValuableAttacker picks the cheapest of the color's attackers, returning
+// its square and piece type. The attackers must not be empty.
+func (p *bitPosition) leastValuableAttacker(attackers Bitboard, color int) (int, int) {
+	for piece := Pawn; piece < King; piece++ {
+		if pieces := attackers & p.pieces[colorIndex(color)][piece]; pieces != 0 {
+			return pieces.popSquare(), piece
+		}
+	}
+	return attackers.popSquare(), King
+}
@@ -0,0 +1,161 @@
+package game
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// mustParseFEN parses a test position, failing the test if it is invalid
+func mustParseFEN(t *testing.T, fen string) *Game {
+	t.Helper()
+	g, err := ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// mustParseSquare parses a test square, failing the test if it is invalid
+func mustParseSquare(t *testing.T, s string) Position {
+	t.Helper()
+	pos, err := ParseSquare(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pos
+}
+
+func TestIsSquareAttacked(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		square string
+		color  int
+		want   bool
+	}{
+		{"pinned knight", "4k3/8/8/8/4r3/8/4N3/4K3 w - - 0 1", "d4", White, true},
+		{"pawn attacks diagonally", "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", "d3", White, true},
+		{"pawn does not attack forward", "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", "e3", White, false},
+		{"slider reaches a blocker", "4k3/8/8/8/8/8/8/R2n3K w - - 0 1", "d1", White, true},
+		{"slider stops at a blocker", "4k3/8/8/8/8/8/8/R2n3K w - - 0 1", "e1", White, false},
+		{"en passant is not an attack", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "d5", White, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustParseFEN(t, tt.fen)
+			if got := IsSquareAttacked(g.Board, mustParseSquare(t, tt.square), tt.color); got != tt.want {
+				t.Errorf("IsSquareAttacked(%s) = %v, want %v", tt.square, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttackersOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		square string
+		color  int
+		want   []string
+	}{
+		{"pinned knight", "4k3/8/8/8/4r3/8/4N3/4K3 w - - 0 1", "d4", White, []string{"e2"}},
+		{"pinning rook", "4k3/8/8/8/4r3/8/4N3/4K3 w - - 0 1", "d4", Black, []string{"e4"}},
+		{"pinned pawn", "4k3/8/8/1b6/8/3P4/8/5K2 w - - 0 1", "e4", White, []string{"d3"}},
+		{"pinning bishop", "4k3/8/8/1b6/8/3P4/8/5K2 w - - 0 1", "c4", Black, []string{"b5"}},
+		{"every piece type", "7k/8/4K3/Q2p4/4PN2/1B6/8/3R4 w - - 0 1", "d5", White, []string{"a5", "b3", "d1", "e4", "e6", "f4"}},
+		{"no attackers", "7k/8/4K3/Q2p4/4PN2/1B6/8/3R4 w - - 0 1", "h2", White, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustParseFEN(t, tt.fen)
+			got := []string{}
+			for _, pos := range AttackersOf(g.Board, mustParseSquare(t, tt.square), tt.color) {
+				got = append(got, pos.String())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AttackersOf(%s) = %v, want %v", tt.square, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeastValuableAttacker(t *testing.T) {
+	g := mustParseFEN(t, "7k/8/4K3/Q2p4/4PN2/1B6/8/3R4 w - - 0 1")
+	p := newBitPosition(g.Board, g)
+	attackers := p.attackersOf(squareIndex(mustParseSquare(t, "d5")), White, p.occupied)
+
+	want := []struct {
+		square string
+		piece  int
+	}{{"e4", Pawn}, {"f4", Knight}, {"b3", Bishop}, {"d1", Rook}, {"a5", Queen}, {"e6", King}}
+	for _, w := range want {
+		sq, piece := p.leastValuableAttacker(attackers, White)
+		if got := squarePosition(sq).String(); got != w.square || piece != w.piece {
+			t.Fatalf("least valuable attacker is %d on %s, want %d on %s", piece, got, w.piece, w.square)
+		}
+		attackers &^= squareBit(sq)
+	}
+	if attackers != 0 {
+		t.Errorf("attackers left over: %v", attackers)
+	}
+}
+
+func TestStaticExchange(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want int
+	}{
+		{"rook takes undefended pawn", "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		{"knight takes defended pawn", "1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", -220},
+		{"x-ray attacker behind a rook", "k3r3/8/8/4p3/8/8/4R3/4R2K w - - 0 1", "e2e5", 100},
+		{"x-ray defender behind a rook", "k3r3/4r3/8/4p3/8/8/4R3/4R2K w - - 0 1", "e2e5", -400},
+		{"king takes undefended pawn", "8/8/8/4p3/3K4/8/8/k7 w - - 0 1", "d4e5", 100},
+		{"king recaptures", "8/8/3k4/4p3/8/5N2/8/4K3 w - - 0 1", "f3e5", -220},
+		{"king cannot recapture on a defended square", "8/8/3k4/4p3/8/5N2/8/4R1K1 w - - 0 1", "f3e5", 100},
+		{"en passant taken back", "4k3/4p3/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 0},
+		{"en passant opens the file", "3rk3/8/8/3pP3/8/8/8/3RK3 w - d6 0 1", "e5d6", 100},
+		{"promotion capture", "r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7a8q", 1300},
+		{"promotion capture taken back", "r3k3/1Pn5/8/8/8/8/8/4K3 w - - 0 1", "b7a8q", 400},
+		{"underpromotion capture taken back", "r3k3/1Pn5/8/8/8/8/8/4K3 w - - 0 1", "b7a8n", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustParseFEN(t, tt.fen)
+			move, err := g.ParseUCI(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.StaticExchange(move); got != tt.want {
+				t.Errorf("StaticExchange(%s) = %d, want %d", tt.move, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestStaticExchangeKingOntoDefendedSquare plays the king into a recapture,
+// which Game.ParseUCI rejects as illegal, to check that losing the king
+// outweighs anything it captures
+func TestStaticExchangeKingOntoDefendedSquare(t *testing.T) {
+	g := mustParseFEN(t, "8/8/3p4/4p3/3K4/8/8/k7 w - - 0 1")
+	p := newBitPosition(g.Board, g)
+	move := p.newMove(squareIndex(mustParseSquare(t, "d4")), squareIndex(mustParseSquare(t, "e5")))
+	if got, want := p.staticExchange(move), pieceValues[Pawn]-kingExchangeValue; got != want {
+		t.Errorf("staticExchange(d4e5) = %d, want %d", got, want)
+	}
+}
+
+func TestThreats(t *testing.T) {
+	g := mustParseFEN(t, "4k3/8/2n1p3/3p4/B3P3/8/8/4K3 b - - 0 1")
+	got := g.Threats()
+	want := []Threat{
+		{Square: mustParseSquare(t, "d5"), Hanging: false}, // Defended by the e6 pawn
+		{Square: mustParseSquare(t, "c6"), Hanging: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Threats() = %v, want %v", got, want)
+	}
+}
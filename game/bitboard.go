@@ -0,0 +1,213 @@
+package game
+
+import "math/bits"
+
+// Bitboard is a set of squares, one bit per square. Bit 0 is a1, bit 7 is h1
+// and bit 63 is h8, so square indexes count up from White's side of the board
+// while Position.Y counts down from Black's.
+type Bitboard uint64
+
+// squareIndex converts a board position to its bit index
+func squareIndex(pos Position) int {
+	return (7-pos.Y)*8 + pos.X
+}
+
+// squarePosition converts a bit index back to a board position
+func squarePosition(sq int) Position {
+	return Position{X: sq % 8, Y: 7 - sq/8}
+}
+
+// squareBit returns the set holding only the given square
+func squareBit(sq int) Bitboard {
+	return 1 << uint(sq)
+}
+
+// Has checks if the square is in the set
+func (b Bitboard) Has(sq int) bool {
+	return b&squareBit(sq) != 0
+}
+
+// Count returns the number of squares in the set
+func (b Bitboard) Count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+// popSquare removes the lowest square from the set and returns its index
+func (b *Bitboard) popSquare() int {
+	sq := bits.TrailingZeros64(uint64(*b))
+	*b &= *b - 1
+	return sq
+}
+
+// Attack tables for the pieces that don't slide, indexed by square
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [2][64]Bitboard // Indexed by colorIndex, then square
+)
+
+// Ray directions as file and rank steps
+var (
+	rookDirections   = [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopDirections = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+// magic holds the lookup table for a slider on one square. Multiplying the
+// relevant blockers by the magic number and shifting gives a unique index
+// into attacks for every blocker pattern.
+type magic struct {
+	mask    Bitboard // Squares whose occupancy affects the attacks, edges excluded
+	number  uint64
+	shift   uint
+	attacks []Bitboard
+}
+
+var rookMagics, bishopMagics [64]magic
+
+// magicSeeds seeds the magic search for the squares of each rank. They are
+// fixed so the tables come out the same on every run, and picked so that
+// suitable magics turn up within a few thousand tries.
+var magicSeeds = [8]uint64{728, 10316, 55013, 32803, 12281, 15100, 16645, 255}
+
+func init() {
+	initLeaperAttacks()
+	for sq := 0; sq < 64; sq++ {
+		rng := xorshift(magicSeeds[sq/8])
+		rookMagics[sq] = findMagic(sq, rookDirections, &rng)
+		rng = xorshift(magicSeeds[sq/8])
+		bishopMagics[sq] = findMagic(sq, bishopDirections, &rng)
+	}
+}
+
+// colorIndex maps White to 0 and Black to 1 for indexing tables
+func colorIndex(color int) int {
+	if color == White {
+		return 0
+	}
+	return 1
+}
+
+// initLeaperAttacks fills the knight, king and pawn attack tables
+func initLeaperAttacks() {
+	knightSteps := [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps := [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+	for sq := 0; sq < 64; sq++ {
+		knightAttacks[sq] = stepAttacks(sq, knightSteps)
+		kingAttacks[sq] = stepAttacks(sq, kingSteps)
+		pawnAttacks[colorIndex(White)][sq] = stepAttacks(sq, [][2]int{{-1, 1}, {1, 1}})
+		pawnAttacks[colorIndex(Black)][sq] = stepAttacks(sq, [][2]int{{-1, -1}, {1, -1}})
+	}
+}
+
+// stepAttacks returns the squares one step away from sq in each direction
+func stepAttacks(sq int, steps [][2]int) Bitboard {
+	var attacks Bitboard
+	file, rank := sq%8, sq/8
+	for _, s := range steps {
+		f, r := file+s[0], rank+s[1]
+		if f >= 0 && f < 8 && r >= 0 && r < 8 {
+			attacks |= squareBit(r*8 + f)
+		}
+	}
+	return attacks
+}
+
+// slidingAttacks walks each ray from sq until it leaves the board or hits a
+// blocker, which is included. It is only used to build the magic tables.
+func slidingAttacks(sq int, directions [][2]int, blockers Bitboard) Bitboard {
+	var attacks Bitboard
+	for _, d := range directions {
+		f, r := sq%8+d[0], sq/8+d[1]
+		for f >= 0 && f < 8 && r >= 0 && r < 8 {
+			bit := squareBit(r*8 + f)
+			attacks |= bit
+			if blockers&bit != 0 {
+				break
+			}
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return attacks
+}
+
+// relevantMask returns the squares along the rays from sq whose occupancy
+// matters. The last square of each ray never blocks anything beyond it.
+func relevantMask(sq int, directions [][2]int) Bitboard {
+	var mask Bitboard
+	for _, d := range directions {
+		f, r := sq%8+d[0], sq/8+d[1]
+		for f+d[0] >= 0 && f+d[0] < 8 && r+d[1] >= 0 && r+d[1] < 8 {
+			mask |= squareBit(r*8 + f)
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return mask
+}
+
+// findMagic searches for a magic number that maps every blocker pattern on
+// the square's rays to a table slot without harmful collisions
+func findMagic(sq int, directions [][2]int, rng *xorshift) magic {
+	mask := relevantMask(sq, directions)
+	n := mask.Count()
+
+	// Enumerate every subset of the mask with the carry-rippler trick
+	size := 1 << uint(n)
+	blockers := make([]Bitboard, 0, size)
+	reference := make([]Bitboard, 0, size)
+	for subset := Bitboard(0); ; {
+		blockers = append(blockers, subset)
+		reference = append(reference, slidingAttacks(sq, directions, subset))
+		subset = (subset - mask) & mask
+		if subset == 0 {
+			break
+		}
+	}
+
+	m := magic{mask: mask, shift: uint(64 - n), attacks: make([]Bitboard, size)}
+	used := make([]int, size) // Attempt that last filled each slot, so tables needn't be cleared
+	for attempt := 1; ; attempt++ {
+		// Sparse numbers make good magics
+		m.number = rng.next() & rng.next() & rng.next()
+		if bits.OnesCount64((uint64(mask)*m.number)>>56) < 6 {
+			continue
+		}
+
+		ok := true
+		for i, b := range blockers {
+			index := (uint64(b) * m.number) >> m.shift
+			if used[index] != attempt {
+				used[index] = attempt
+				m.attacks[index] = reference[i]
+			} else if m.attacks[index] != reference[i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return m
+		}
+	}
+}
+
+// xorshift is a small deterministic random number generator
+type xorshift uint64
+
+func (x *xorshift) next() uint64 {
+	*x ^= *x >> 12
+	*x ^= *x << 25
+	*x ^= *x >> 27
+	return uint64(*x) * 0x2545F4914F6CDD1D
+}
+
+// rookAttacks returns the squares a rook on sq attacks given the occupied squares
+func rookAttacks(sq int, occupied Bitboard) Bitboard {
+	m := &rookMagics[sq]
+	return m.attacks[(uint64(occupied&m.mask)*m.number)>>m.shift]
+}
+
+// bishopAttacks returns the squares a bishop on sq attacks given the occupied squares
+func bishopAttacks(sq int, occupied Bitboard) Bitboard {
+	m := &bishopMagics[sq]
+	return m.attacks[(uint64(occupied&m.mask)*m.number)>>m.shift]
+}
@@ -0,0 +1,375 @@
+package game
+
+// bitPosition is the bitboard form of a position that move generation and
+// legality checks work on. It is built from a board and, optionally, the game
+// state that goes with it.
+type bitPosition struct {
+	pieces    [2][7]Bitboard // Squares of each piece type, by colorIndex and piece
+	colors    [2]Bitboard    // Squares of all pieces of each color
+	occupied  Bitboard
+	squares   [64]int8 // Piece on each square, signed by color
+	turn      int      // Color to move
+	castling  CastlingRights
+	enPassant int // En passant target square, or -1
+
+	halfmoveClock  int
+	fullmoveNumber int
+}
+
+// newBitPosition converts a board to bitboards. Without game state it is
+// White's turn, nobody may castle and there is no en passant target.
+func newBitPosition(board [8][8]int, game *Game) *bitPosition {
+	p := &bitPosition{turn: White, enPassant: -1, fullmoveNumber: 1}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := board[y][x]; piece != Empty {
+				p.put(squareIndex(Position{X: x, Y: y}), piece)
+			}
+		}
+	}
+
+	if game != nil {
+		p.turn = boolToInt(game.Turn, White, Black)
+		p.castling = game.Castling
+		if game.EnPassantTarget != nil {
+			p.enPassant = squareIndex(*game.EnPassantTarget)
+		}
+		p.halfmoveClock = game.HalfmoveClock
+		p.fullmoveNumber = game.FullmoveNumber
+	}
+	return p
+}
+
+// put places a piece on an empty square
+func (p *bitPosition) put(sq, piece int) {
+	bit := squareBit(sq)
+	ci := colorIndex(sign(piece))
+	p.pieces[ci][abs(piece)] |= bit
+	p.colors[ci] |= bit
+	p.occupied |= bit
+	p.squares[sq] = int8(piece)
+}
+
+// remove takes the piece off a square
+func (p *bitPosition) remove(sq int) {
+	piece := int(p.squares[sq])
+	if piece == Empty {
+		return
+	}
+	bit := squareBit(sq)
+	ci := colorIndex(sign(piece))
+	p.pieces[ci][abs(piece)] &^= bit
+	p.colors[ci] &^= bit
+	p.occupied &^= bit
+	p.squares[sq] = Empty
+}
+
+// kingSquare returns the square of the color's king, or -1 if it has none
+func (p *bitPosition) kingSquare(color int) int {
+	king := p.pieces[colorIndex(color)][King]
+	if king == 0 {
+		return -1
+	}
+	return king.popSquare()
+}
+
+// attackersOf returns the pieces of the given color that attack sq, looking
+// outward from sq: along the rays for sliders, a knight's jump away for
+// knights, one step diagonally for pawns and one step in any direction for
+// the king. Sliders are blocked by the occupied squares, which may differ
+// from the position's own, and only pieces on occupied squares count.
+func (p *bitPosition) attackersOf(sq, color int, occupied Bitboard) Bitboard {
+	own := &p.pieces[colorIndex(color)]
+	// A pawn attacks sq if a pawn of the other color on sq would attack it back
+	attackers := pawnAttacks[colorIndex(-color)][sq] & own[Pawn]
+	attackers |= knightAttacks[sq] & own[Knight]
+	attackers |= kingAttacks[sq] & own[King]
+	attackers |= bishopAttacks(sq, occupied) & (own[Bishop] | own[Queen])
+	attackers |= rookAttacks(sq, occupied) & (own[Rook] | own[Queen])
+	return attackers & occupied
+}
+
+// isAttacked checks if any piece of the given color attacks sq
+func (p *bitPosition) isAttacked(sq, byColor int) bool {
+	return p.attackersOf(sq, byColor, p.occupied) != 0
+}
+
+// inCheck checks if the color's king is attacked
+func (p *bitPosition) inCheck(color int) bool {
+	king := p.kingSquare(color)
+	return king >= 0 && p.isAttacked(king, -color)
+}
+
+// newMove builds an ordinary move between two squares, marking captures
+func (p *bitPosition) newMove(from, to int) Move {
+	move := Move{
+		From:     squarePosition(from),
+		To:       squarePosition(to),
+		Piece:    int(p.squares[from]),
+		Captured: int(p.squares[to]),
+	}
+	if move.Captured != Empty {
+		move.Flags |= FlagCapture
+	}
+	return move
+}
+
+// appendMoves adds a move from one square to each of the targets
+func (p *bitPosition) appendMoves(moves []Move, from int, targets Bitboard) []Move {
+	for targets != 0 {
+		moves = append(moves, p.newMove(from, targets.popSquare()))
+	}
+	return moves
+}
+
+// pseudoMoves appends the moves of the color's pieces standing on the from
+// squares, without checking whether they leave the king in check. Castling is
+// only generated when the king doesn't start on or pass through an attacked
+// square.
+func (p *bitPosition) pseudoMoves(moves []Move, color int, from Bitboard) []Move {
+	ci := colorIndex(color)
+	own := &p.pieces[ci]
+	targets := ^p.colors[ci] // Empty or enemy squares
+
+	for pawns := own[Pawn] & from; pawns != 0; {
+		moves = p.pawnMoves(moves, pawns.popSquare(), color)
+	}
+	for knights := own[Knight] & from; knights != 0; {
+		sq := knights.popSquare()
+		moves = p.appendMoves(moves, sq, knightAttacks[sq]&targets)
+	}
+	for bishops := own[Bishop] & from; bishops != 0; {
+		sq := bishops.popSquare()
+		moves = p.appendMoves(moves, sq, bishopAttacks(sq, p.occupied)&targets)
+	}
+	for rooks := own[Rook] & from; rooks != 0; {
+		sq := rooks.popSquare()
+		moves = p.appendMoves(moves, sq, rookAttacks(sq, p.occupied)&targets)
+	}
+	for queens := own[Queen] & from; queens != 0; {
+		sq := queens.popSquare()
+		moves = p.appendMoves(moves, sq, (bishopAttacks(sq, p.occupied)|rookAttacks(sq, p.occupied))&targets)
+	}
+	for kings := own[King] & from; kings != 0; {
+		sq := kings.popSquare()
+		moves = p.appendMoves(moves, sq, kingAttacks[sq]&targets)
+		moves = p.castlingMoves(moves, sq, color)
+	}
+	return moves
+}
+
+// pawnMoves appends the pushes and captures of the pawn on sq. Moves onto
+// the last rank are added once for each promotion piece.
+func (p *bitPosition) pawnMoves(moves []Move, sq, color int) []Move {
+	forward := 8 * color // Square indexes count up toward Black's side
+	startRank := boolToInt(color == White, 1, 6)
+
+	// Forward moves
+	if to := sq + forward; to >= 0 && to < 64 && !p.occupied.Has(to) {
+		moves = appendPawnMove(moves, p.newMove(sq, to))
+
+		if to2 := to + forward; sq/8 == startRank && !p.occupied.Has(to2) {
+			move := p.newMove(sq, to2)
+			move.Flags |= FlagDoublePush
+			moves = append(moves, move)
+		}
+	}
+
+	// Captures
+	attacks := pawnAttacks[colorIndex(color)][sq]
+	for captures := attacks & p.colors[colorIndex(-color)]; captures != 0; {
+		moves = appendPawnMove(moves, p.newMove(sq, captures.popSquare()))
+	}
+
+	// En passant, onto the square behind a pawn of the other color that just moved two squares
+	epRank := boolToInt(color == White, 5, 2)
+	if p.enPassant >= 0 && p.enPassant/8 == epRank && attacks.Has(p.enPassant) {
+		move := p.newMove(sq, p.enPassant)
+		move.Captured = -color * Pawn
+		move.Flags |= FlagCapture | FlagEnPassant
+		moves = append(moves, move)
+	}
+
+	return moves
+}
+
+// castlingMoves appends the castling moves of the king on sq
+func (p *bitPosition) castlingMoves(moves []Move, sq, color int) []Move {
+	home := boolToInt(color == White, 0, 56) // a1 or a8
+	if sq != home+4 || p.inCheck(color) {
+		return moves
+	}
+
+	rook := int8(color * Rook)
+	if p.castling.Has(kingsideRight(color)) &&
+		p.squares[home+7] == rook && // Rook is still in place
+		p.occupied&(squareBit(home+5)|squareBit(home+6)) == 0 && // Squares between are empty
+		!p.isAttacked(home+5, -color) { // King doesn't pass through check
+		move := p.newMove(sq, home+6)
+		move.Flags |= FlagKingsideCastle
+		moves = append(moves, move)
+	}
+
+	if p.castling.Has(queensideRight(color)) &&
+		p.squares[home] == rook && // Rook is still in place
+		p.occupied&(squareBit(home+1)|squareBit(home+2)|squareBit(home+3)) == 0 && // Squares between are empty
+		!p.isAttacked(home+3, -color) { // King doesn't pass through check
+		move := p.newMove(sq, home+2)
+		move.Flags |= FlagQueensideCastle
+		moves = append(moves, move)
+	}
+
+	return moves
+}
+
+// undoState holds what unmakeMove needs to restore that the move itself
+// doesn't record
+type undoState struct {
+	captured       int8 // Piece the move took, or Empty
+	castling       CastlingRights
+	enPassant      int
+	halfmoveClock  int
+	fullmoveNumber int
+}
+
+// captureSquare returns the square of the piece a move takes, which is beside
+// the target square for en passant
+func captureSquare(move Move) int {
+	if move.Is(FlagEnPassant) {
+		return squareIndex(Position{X: move.To.X, Y: move.From.Y})
+	}
+	return squareIndex(move.To)
+}
+
+// makeMove plays a move in place, updating the castling rights, en passant
+// target, move counters and side to move the same way Game.MakeMove does. The
+// returned state takes it back with unmakeMove.
+func (p *bitPosition) makeMove(move Move) undoState {
+	undo := undoState{
+		castling:       p.castling,
+		enPassant:      p.enPassant,
+		halfmoveClock:  p.halfmoveClock,
+		fullmoveNumber: p.fullmoveNumber,
+	}
+	from, to := squareIndex(move.From), squareIndex(move.To)
+	piece := int(p.squares[from])
+	color := sign(piece)
+
+	// Take off the captured piece
+	captured := captureSquare(move)
+	undo.captured = p.squares[captured]
+	p.remove(captured)
+
+	// Move the piece, promoting it if needed
+	p.remove(from)
+	if move.IsPromotion() {
+		p.put(to, color*move.Promotion)
+	} else {
+		p.put(to, piece)
+	}
+
+	// Move the castling rook
+	if move.Is(FlagKingsideCastle) {
+		p.remove(from + 3)
+		p.put(from+1, color*Rook)
+	} else if move.Is(FlagQueensideCastle) {
+		p.remove(from - 4)
+		p.put(from-1, color*Rook)
+	}
+
+	// Update castling rights
+	if abs(piece) == King {
+		p.castling &^= kingsideRight(color) | queensideRight(color)
+	}
+	p.castling &^= castlingRightForSquare(move.From) | castlingRightForSquare(move.To)
+
+	// Update en passant target
+	p.enPassant = -1
+	if move.Is(FlagDoublePush) {
+		p.enPassant = (from + to) / 2
+	}
+
+	// Update move counters
+	if abs(piece) == Pawn || undo.captured != Empty {
+		p.halfmoveClock = 0
+	} else {
+		p.halfmoveClock++
+	}
+	if color == Black {
+		p.fullmoveNumber++
+	}
+
+	p.turn = -color
+	return undo
+}
+
+// unmakeMove takes back a move played with makeMove, restoring the position
+// exactly as it was
+func (p *bitPosition) unmakeMove(move Move, undo undoState) {
+	from, to := squareIndex(move.From), squareIndex(move.To)
+	piece := int(p.squares[to])
+	color := sign(piece)
+
+	// Put the moving piece back, turning a promoted piece back into a pawn
+	if move.IsPromotion() {
+		piece = color * Pawn
+	}
+	p.remove(to)
+	p.put(from, piece)
+
+	// Restore the captured piece
+	if undo.captured != Empty {
+		p.put(captureSquare(move), int(undo.captured))
+	}
+
+	// Move the castling rook back
+	if move.Is(FlagKingsideCastle) {
+		p.remove(from + 1)
+		p.put(from+3, color*Rook)
+	} else if move.Is(FlagQueensideCastle) {
+		p.remove(from - 1)
+		p.put(from-4, color*Rook)
+	}
+
+	p.castling = undo.castling
+	p.enPassant = undo.enPassant
+	p.halfmoveClock = undo.halfmoveClock
+	p.fullmoveNumber = undo.fullmoveNumber
+	p.turn = color
+}
+
+// isLegal checks that a move doesn't leave the mover's own king in check
+func (p *bitPosition) isLegal(move Move) bool {
+	color := sign(int(p.squares[squareIndex(move.From)]))
+	undo := p.makeMove(move)
+	legal := !p.inCheck(color)
+	p.unmakeMove(move, undo)
+	return legal
+}
+
+// legalMoves appends the legal moves of the color's pieces standing on the
+// from squares
+func (p *bitPosition) legalMoves(moves []Move, color int, from Bitboard) []Move {
+	start := len(moves)
+	moves = p.pseudoMoves(moves, color, from)
+
+	// Filter in place, keeping the moves that don't leave the king in check
+	legal := moves[:start]
+	for _, move := range moves[start:] {
+		if p.isLegal(move) {
+			legal = append(legal, move)
+		}
+	}
+	return legal
+}
+
+// hasLegalMoves checks if the color has any legal move
+func (p *bitPosition) hasLegalMoves(color int) bool {
+	ci := colorIndex(color)
+	for pieces := p.colors[ci]; pieces != 0; {
+		if len(p.legalMoves(nil, color, squareBit(pieces.popSquare()))) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMakeUnmake walks the perft positions with makeMove and unmakeMove,
+// checking that every unmake restores the position exactly: bitboards, the
+// square table, castling rights, the en passant target and both clocks
+func TestMakeUnmake(t *testing.T) {
+	const depth = 3
+	for _, tt := range perftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := newBitPosition(g.Board, g)
+			if got, want := makeUnmakePerft(t, p, depth), tt.nodes[depth-1]; got != want {
+				t.Errorf("depth %d: got %d nodes, want %d", depth, got, want)
+			}
+		})
+	}
+}
+
+// makeUnmakePerft counts leaf nodes, comparing the position before each move
+// with the one left after taking it back
+func makeUnmakePerft(t *testing.T, p *bitPosition, depth int) int64 {
+	if depth == 0 {
+		return 1
+	}
+
+	var nodes int64
+	for _, move := range p.legalMoves(nil, p.turn, p.colors[colorIndex(p.turn)]) {
+		before := *p
+		undo := p.makeMove(move)
+		nodes += makeUnmakePerft(t, p, depth-1)
+		p.unmakeMove(move, undo)
+		if !reflect.DeepEqual(*p, before) {
+			t.Fatalf("unmaking %s gave %+v, want %+v", move.UCI(), *p, before)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,116 @@
+package game
+
+import "fmt"
+
+// CastlingRights records which castling moves are still available. A right is
+// lost once the king or the corresponding rook moves, or the rook is captured.
+type CastlingRights uint8
+
+const (
+	WhiteKingside CastlingRights = 1 << iota
+	WhiteQueenside
+	BlackKingside
+	BlackQueenside
+
+	NoCastling  CastlingRights = 0
+	AllCastling                = WhiteKingside | WhiteQueenside | BlackKingside | BlackQueenside
+)
+
+// castlingLetters maps each right to its letter in position strings, in KQkq order
+var castlingLetters = []struct {
+	right  CastlingRights
+	letter byte
+}{
+	{WhiteKingside, 'K'},
+	{WhiteQueenside, 'Q'},
+	{BlackKingside, 'k'},
+	{BlackQueenside, 'q'},
+}
+
+// Has checks if all of the given rights are available
+func (c CastlingRights) Has(rights CastlingRights) bool {
+	return c&rights == rights
+}
+
+// String returns the rights in KQkq notation, or "-" if none are left
+func (c CastlingRights) String() string {
+	s := ""
+	for _, cl := range castlingLetters {
+		if c.Has(cl.right) {
+			s += string(cl.letter)
+		}
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// ParseCastlingRights parses castling rights in KQkq notation
+func ParseCastlingRights(s string) (CastlingRights, error) {
+	if s == "-" {
+		return NoCastling, nil
+	}
+	if s == "" {
+		return NoCastling, fmt.Errorf("empty castling rights")
+	}
+
+	rights := NoCastling
+	next := 0 // Letters must appear in KQkq order
+	for i := 0; i < len(s); i++ {
+		found := false
+		for j := next; j < len(castlingLetters); j++ {
+			if s[i] == castlingLetters[j].letter {
+				rights |= castlingLetters[j].right
+				next = j + 1
+				found = true
+				break
+			}
+		}
+		if !found {
+			return NoCastling, fmt.Errorf("invalid castling rights %q: unexpected %q", s, s[i])
+		}
+	}
+	return rights, nil
+}
+
+// kingsideRight returns the kingside castling right for a color
+func kingsideRight(color int) CastlingRights {
+	if color == White {
+		return WhiteKingside
+	}
+	return BlackKingside
+}
+
+// queensideRight returns the queenside castling right for a color
+func queensideRight(color int) CastlingRights {
+	if color == White {
+		return WhiteQueenside
+	}
+	return BlackQueenside
+}
+
+// castlingRightForSquare returns the right tied to a rook's home square, if any
+func castlingRightForSquare(pos Position) CastlingRights {
+	switch pos {
+	case Position{7, 7}:
+		return WhiteKingside
+	case Position{0, 7}:
+		return WhiteQueenside
+	case Position{7, 0}:
+		return BlackKingside
+	case Position{0, 0}:
+		return BlackQueenside
+	}
+	return NoCastling
+}
+
+// updateCastlingRights removes the rights lost by moving the given piece
+// from one square to another
+func (g *Game) updateCastlingRights(piece int, from, to Position) {
+	if abs(piece) == King {
+		g.Castling &^= kingsideRight(sign(piece)) | queensideRight(sign(piece))
+	}
+	// A rook leaving its home square or being captured there
+	g.Castling &^= castlingRightForSquare(from) | castlingRightForSquare(to)
+}
@@ -0,0 +1,137 @@
+package game
+
+import "sort"
+
+// pieceValues gives the material value of each piece type in centipawns
+var pieceValues = [...]int{
+	Empty:  0,
+	Pawn:   100,
+	Knight: 320,
+	Bishop: 330,
+	Rook:   500,
+	Queen:  900,
+	King:   0,
+}
+
+// mateScore is the score for delivering checkmate; mates found sooner score higher
+const mateScore = 100000
+
+// maxPly bounds the search depth, so scores within it of mateScore are mates
+const maxPly = 256
+
+// SearchResult is the outcome of a search started with StartSearch
+type SearchResult struct {
+	Move  Move
+	Score int  // Centipawns from the point of view of the side to move
+	OK    bool // False if the side to move has no legal moves
+}
+
+// MateIn returns the number of moves in which the side to move gives
+// checkmate, negated if it is the one getting mated, or 0 if the score is
+// not a forced mate
+func (r SearchResult) MateIn() int {
+	switch {
+	case r.Score > mateScore-maxPly:
+		return (mateScore - r.Score + 1) / 2
+	case r.Score < -mateScore+maxPly:
+		return -(mateScore + r.Score) / 2
+	}
+	return 0
+}
+
+// BestMove searches depth plies ahead with alpha-beta pruning and returns the
+// best move for the side to move, judged by material. It returns false if the
+// side to move has no legal moves.
+func (g *Game) BestMove(depth int) (Move, bool) {
+	result := newBitPosition(g.Board, g).bestMove(depth)
+	return result.Move, result.OK
+}
+
+// StartSearch runs BestMove's search in the background. The position is
+// copied first, so the game can go on changing while the search runs. The
+// result is sent on the returned channel, which has room for it, so an
+// abandoned search finishes without anyone reading it.
+func (g *Game) StartSearch(depth int) <-chan SearchResult {
+	p := newBitPosition(g.Board, g)
+	results := make(chan SearchResult, 1)
+	go func() {
+		results <- p.bestMove(depth)
+	}()
+	return results
+}
+
+// bestMove searches the root position, trying every legal move
+func (p *bitPosition) bestMove(depth int) SearchResult {
+	moves := p.legalMoves(nil, p.turn, p.colors[colorIndex(p.turn)])
+	if len(moves) == 0 {
+		return SearchResult{}
+	}
+	orderMoves(moves)
+
+	best := moves[0]
+	alpha := -mateScore - 1
+	for _, move := range moves {
+		undo := p.makeMove(move)
+		score := -p.search(depth-1, 1, -mateScore-1, -alpha)
+		p.unmakeMove(move, undo)
+		if score > alpha {
+			alpha = score
+			best = move
+		}
+	}
+	return SearchResult{Move: best, Score: alpha, OK: true}
+}
+
+// search returns the negamax score of the position for the side to move
+func (p *bitPosition) search(depth, ply, alpha, beta int) int {
+	moves := p.legalMoves(nil, p.turn, p.colors[colorIndex(p.turn)])
+	if len(moves) == 0 {
+		if p.inCheck(p.turn) {
+			return -mateScore + ply
+		}
+		return 0
+	}
+	if depth <= 0 {
+		return p.evaluate()
+	}
+
+	orderMoves(moves)
+	for _, move := range moves {
+		undo := p.makeMove(move)
+		score := -p.search(depth-1, ply+1, -beta, -alpha)
+		p.unmakeMove(move, undo)
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha
+}
+
+// evaluate scores the material balance from the point of view of the side to move
+func (p *bitPosition) evaluate() int {
+	score := 0
+	for piece := Pawn; piece <= King; piece++ {
+		score += pieceValues[piece] * (p.pieces[colorIndex(White)][piece].Count() - p.pieces[colorIndex(Black)][piece].Count())
+	}
+	return score * p.turn
+}
+
+// orderMoves puts captures and promotions first, most valuable victim first,
+// so alpha-beta prunes more of the tree
+func orderMoves(moves []Move) {
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moveGain(moves[i]) > moveGain(moves[j])
+	})
+}
+
+// moveGain estimates how much material a move wins outright
+func moveGain(move Move) int {
+	gain := pieceValues[abs(move.Captured)]
+	if move.IsPromotion() {
+		gain += pieceValues[move.Promotion] - pieceValues[Pawn]
+	}
+	return gain
+}
@@ -0,0 +1,92 @@
+package game
+
+import "testing"
+
+func TestBestMove(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want string
+	}{
+		{"scholar's mate", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "h5f7"},
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8"},
+		{"fool's mate", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4"},
+		{"mate beats winning the queen", "6k1/5ppp/8/8/3q4/8/8/3RR2K w - - 0 1", "e1e8"},
+		{"wins a hanging queen", "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", "d1d5"},
+		{"wins a hanging knight", "4k3/8/8/8/2n5/1P6/8/4K3 w - - 0 1", "b3c4"},
+		{"promotes", "4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustParseFEN(t, tt.fen)
+			move, ok := g.BestMove(3)
+			if !ok {
+				t.Fatal("no move found")
+			}
+			if move.UCI() != tt.want {
+				t.Errorf("got %s, want %s", move.UCI(), tt.want)
+			}
+			if g.FEN() != tt.fen {
+				t.Errorf("searching changed the position to %s", g.FEN())
+			}
+		})
+	}
+}
+
+func TestBestMoveAvoidsDefendedPiece(t *testing.T) {
+	// Taking the knight loses the queen to the e7 pawn
+	g := mustParseFEN(t, "4k3/4p3/3n4/8/8/8/8/3QK3 w - - 0 1")
+	move, ok := g.BestMove(3)
+	if !ok {
+		t.Fatal("no move found")
+	}
+	if move.UCI() == "d1d6" {
+		t.Errorf("got %s, which loses the queen", move.UCI())
+	}
+}
+
+func TestBestMoveWithoutLegalMoves(t *testing.T) {
+	for _, fen := range []string{
+		"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", // Stalemate
+		"7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", // Checkmate
+	} {
+		g := mustParseFEN(t, fen)
+		if move, ok := g.BestMove(3); ok {
+			t.Errorf("%s: got %s, want no move", fen, move.UCI())
+		}
+	}
+}
+
+func TestStartSearch(t *testing.T) {
+	g := mustParseFEN(t, "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1")
+	results := g.StartSearch(3)
+
+	// The search works on a copy, so the game can move on meanwhile
+	g.MakeMove(g.LegalMoves()[0])
+
+	result := <-results
+	if !result.OK || result.Move.UCI() != "d1d5" {
+		t.Errorf("got %s (ok %v), want d1d5", result.Move.UCI(), result.OK)
+	}
+	if result.Score != pieceValues[Rook] {
+		t.Errorf("score %d, want %d for the rook left once the queen is taken", result.Score, pieceValues[Rook])
+	}
+}
+
+func TestSearchResultMateIn(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want int
+	}{
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 1}, // Ra8#
+		{"k7/8/1K6/8/8/8/8/7R b - - 0 1", -1},    // Kb8, then Rh8#
+		{"4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", 0},  // Only wins material
+		{"7k/8/8/8/8/8/R7/1R5K w - - 0 1", 2},    // Rb7, then Ra8#
+	}
+	for _, tt := range tests {
+		g := mustParseFEN(t, tt.fen)
+		if got := (<-g.StartSearch(4)).MateIn(); got != tt.want {
+			t.Errorf("%s: mate in %d, want %d", tt.fen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EPDOperation is one opcode of an EPD record with its operands, such as
+// bm Nf3 Nc3 or id "WAC.001". String operands are stored without quotes.
+type EPDOperation struct {
+	Opcode   string
+	Operands []string
+}
+
+// EPD is a position in Extended Position Description format together with
+// its operations, in the order they were written
+type EPD struct {
+	Game       *Game
+	Operations []EPDOperation
+}
+
+// Op returns the operands of the first operation with the given opcode
+func (e *EPD) Op(opcode string) ([]string, bool) {
+	for _, op := range e.Operations {
+		if op.Opcode == opcode {
+			return op.Operands, true
+		}
+	}
+	return nil, false
+}
+
+// ID returns the "id" operand, or an empty string if there is none
+func (e *EPD) ID() string {
+	if operands, ok := e.Op("id"); ok && len(operands) > 0 {
+		return operands[0]
+	}
+	return ""
+}
+
+// Comment returns the "c0" comment operand, or an empty string if there is none
+func (e *EPD) Comment() string {
+	if operands, ok := e.Op("c0"); ok && len(operands) > 0 {
+		return operands[0]
+	}
+	return ""
+}
+
+// AnalysisDepth returns the "acd" analysis depth, or false if there is none
+func (e *EPD) AnalysisDepth() (int, bool) {
+	operands, ok := e.Op("acd")
+	if !ok || len(operands) == 0 {
+		return 0, false
+	}
+	depth, err := strconv.Atoi(operands[0])
+	return depth, err == nil
+}
+
+// BestMoves resolves the "bm" operands, given in SAN, to legal moves
+func (e *EPD) BestMoves() ([]Move, error) {
+	return e.moves("bm")
+}
+
+// AvoidMoves resolves the "am" operands, given in SAN, to legal moves
+func (e *EPD) AvoidMoves() ([]Move, error) {
+	return e.moves("am")
+}
+
+// moves resolves the SAN operands of an opcode to legal moves
+func (e *EPD) moves(opcode string) ([]Move, error) {
+	operands, _ := e.Op(opcode)
+	moves := make([]Move, 0, len(operands))
+	for _, san := range operands {
+		move, err := e.Game.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", opcode, err)
+		}
+		moves = append(moves, move)
+	}
+	return moves, nil
+}
+
+// ParseEPD parses an EPD record: the first four FEN fields followed by
+// semicolon-terminated operations. The "hmvc" and "fmvn" opcodes, if present,
+// set the move counters of the position.
+func ParseEPD(line string) (*EPD, error) {
+	line = strings.TrimSpace(line)
+
+	// The position takes the first four fields
+	fields := make([]string, 0, 4)
+	rest := line
+	for len(fields) < 4 {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return nil, fmt.Errorf("invalid EPD %q: expected 4 position fields, got %d", line, len(fields))
+		}
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+
+	operations, err := parseEPDOperations(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EPD %q: %v", line, err)
+	}
+
+	fen := strings.Join(fields, " ")
+	epd := &EPD{Operations: operations}
+	if operands, ok := epd.Op("hmvc"); ok && len(operands) == 1 {
+		fen += " " + operands[0]
+		if operands, ok := epd.Op("fmvn"); ok && len(operands) == 1 {
+			fen += " " + operands[0]
+		} else {
+			fen += " 1"
+		}
+	}
+	if epd.Game, err = ParseFEN(fen); err != nil {
+		return nil, fmt.Errorf("invalid EPD %q: %v", line, err)
+	}
+	return epd, nil
+}
+
+// parseEPDOperations splits the operation section of an EPD record
+func parseEPDOperations(s string) ([]EPDOperation, error) {
+	var operations []EPDOperation
+	var current *EPDOperation
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == ';':
+			if current == nil {
+				return nil, fmt.Errorf("empty operation at offset %d", i)
+			}
+			operations = append(operations, *current)
+			current = nil
+			i++
+
+		case c == '"':
+			if current == nil {
+				return nil, fmt.Errorf("string operand without an opcode at offset %d", i)
+			}
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string operand at offset %d", i)
+			}
+			current.Operands = append(current.Operands, s[i+1:i+1+end])
+			i += end + 2
+
+		default:
+			end := strings.IndexAny(s[i:], " \t;")
+			if end < 0 {
+				end = len(s) - i
+			}
+			token := s[i : i+end]
+			if current == nil {
+				current = &EPDOperation{Opcode: token}
+			} else {
+				current.Operands = append(current.Operands, token)
+			}
+			i += end
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("operation %q is missing its terminating \";\"", current.Opcode)
+	}
+	return operations, nil
+}
+
+// MoveChooser picks a move for a position, such as an engine's best move
+type MoveChooser func(g *Game) Move
+
+// EPDResult is the outcome of one position in a test suite
+type EPDResult struct {
+	EPD    *EPD
+	Chosen Move
+	Solved bool
+	Err    error // Set if the position's expected moves could not be read
+}
+
+// EPDSuiteResult summarizes a test suite run
+type EPDSuiteResult struct {
+	Results []EPDResult
+	Solved  int
+	Total   int
+}
+
+// ReadEPDSuite reads one EPD record per line. Blank lines and lines starting
+// with "#" are skipped. Errors name the line they occurred on.
+func ReadEPDSuite(r io.Reader) ([]*EPD, error) {
+	var suite []*EPD
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		epd, err := ParseEPD(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		suite = append(suite, epd)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return suite, nil
+}
+
+// LoadEPDSuite reads an EPD test suite file
+func LoadEPDSuite(path string) ([]*EPD, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadEPDSuite(f)
+}
+
+// RunEPDSuite gives every position to choose and scores the answers. A
+// position is solved if the chosen move is one of its "bm" moves and none of
+// its "am" moves; positions with neither opcode are not scored.
+func RunEPDSuite(suite []*EPD, choose MoveChooser) EPDSuiteResult {
+	var summary EPDSuiteResult
+	for _, epd := range suite {
+		result := EPDResult{EPD: epd}
+		best, err := epd.BestMoves()
+		if err == nil {
+			var avoid []Move
+			avoid, err = epd.AvoidMoves()
+			if err == nil && (len(best) > 0 || len(avoid) > 0) {
+				result.Chosen = choose(epd.Game)
+				result.Solved = (len(best) == 0 || containsMove(best, result.Chosen)) &&
+					!containsMove(avoid, result.Chosen)
+				summary.Total++
+				if result.Solved {
+					summary.Solved++
+				}
+			}
+		}
+		result.Err = err
+		summary.Results = append(summary.Results, result)
+	}
+	return summary
+}
+
+// containsMove checks if a move is in a list, comparing squares and promotion
+func containsMove(moves []Move, move Move) bool {
+	for _, m := range moves {
+		if m.From == move.From && m.To == move.To && m.Promotion == move.Promotion {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartFEN is the standard starting position in Forsyth-Edwards Notation
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// pieceLetters maps piece types to their lowercase FEN letters
+var pieceLetters = map[int]byte{
+	Pawn:   'p',
+	Knight: 'n',
+	Bishop: 'b',
+	Rook:   'r',
+	Queen:  'q',
+	King:   'k',
+}
+
+// pieceFromLetter converts a FEN letter into a signed piece, uppercase for white
+func pieceFromLetter(letter byte) (int, bool) {
+	color := White
+	if letter >= 'a' && letter <= 'z' {
+		color = Black
+		letter -= 'a' - 'A'
+	}
+	for piece, l := range pieceLetters {
+		if l-('a'-'A') == letter {
+			return color * piece, true
+		}
+	}
+	return Empty, false
+}
+
+// pieceLetter returns the FEN letter for a signed piece, uppercase for white
+func pieceLetter(piece int) byte {
+	letter := pieceLetters[abs(piece)]
+	if piece > 0 {
+		letter -= 'a' - 'A'
+	}
+	return letter
+}
+
+// ParseFEN creates a game from a position in Forsyth-Edwards Notation. The
+// halfmove clock and fullmove number may be omitted, in which case they
+// default to 0 and 1. The position is checked for consistency: each side needs
+// exactly one king, pawns cannot stand on the first or last rank, the side
+// that just moved cannot be in check, and castling rights and the en passant
+// square must match the pieces on the board.
+func ParseFEN(fen string) (*Game, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		return nil, fmt.Errorf("invalid FEN %q: expected 4 or 6 fields, got %d", fen, len(fields))
+	}
+
+	g := &Game{
+		State:          Playing,
+		FullmoveNumber: 1,
+	}
+
+	// Piece placement
+	if err := parsePlacement(&g.Board, fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+	}
+
+	// Side to move
+	switch fields[1] {
+	case "w":
+		g.Turn = true
+	case "b":
+		g.Turn = false
+	default:
+		return nil, fmt.Errorf("invalid FEN %q: side to move must be \"w\" or \"b\", got %q", fen, fields[1])
+	}
+
+	// Castling rights
+	castling, err := ParseCastlingRights(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+	}
+	g.Castling = castling
+
+	// En passant square
+	if fields[3] != "-" {
+		target, err := ParseSquare(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid FEN %q: invalid en passant square: %v", fen, err)
+		}
+		g.EnPassantTarget = &target
+	}
+
+	// Move counters
+	if len(fields) == 6 {
+		g.HalfmoveClock, err = strconv.Atoi(fields[4])
+		if err != nil || g.HalfmoveClock < 0 {
+			return nil, fmt.Errorf("invalid FEN %q: halfmove clock must be a non-negative number, got %q", fen, fields[4])
+		}
+		g.FullmoveNumber, err = strconv.Atoi(fields[5])
+		if err != nil || g.FullmoveNumber < 1 {
+			return nil, fmt.Errorf("invalid FEN %q: fullmove number must be a positive number, got %q", fen, fields[5])
+		}
+	}
+
+	if err := g.validatePosition(); err != nil {
+		return nil, fmt.Errorf("invalid FEN %q: %v", fen, err)
+	}
+
+	// Remember non-standard starting positions for PGN export
+	if normalized := g.FEN(); normalized != StartFEN {
+		g.InitialFEN = normalized
+	}
+
+	g.Hash = g.ComputeHash()
+	g.recordPosition()
+	g.UpdateGameState()
+	return g, nil
+}
+
+// parsePlacement fills the board from the piece placement field
+func parsePlacement(board *[8][8]int, placement string) error {
+	ranks := strings.Split(placement, "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("piece placement must have 8 ranks, got %d", len(ranks))
+	}
+
+	for y, rank := range ranks {
+		x := 0
+		for i := 0; i < len(rank); i++ {
+			c := rank[i]
+			if c >= '1' && c <= '8' {
+				x += int(c - '0')
+				continue
+			}
+			piece, ok := pieceFromLetter(c)
+			if !ok {
+				return fmt.Errorf("invalid piece %q on rank %d", c, 8-y)
+			}
+			if x >= 8 {
+				return fmt.Errorf("rank %d has more than 8 squares", 8-y)
+			}
+			board[y][x] = piece
+			x++
+		}
+		if x != 8 {
+			return fmt.Errorf("rank %d has %d squares, expected 8", 8-y, x)
+		}
+	}
+	return nil
+}
+
+// validatePosition checks that a loaded position could occur in a game
+func (g *Game) validatePosition() error {
+	// Each side needs exactly one king, and pawns can't stand on the back ranks
+	kings := map[int]int{}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := g.Board[y][x]
+			if abs(piece) == King {
+				kings[sign(piece)]++
+			}
+			if abs(piece) == Pawn && (y == 0 || y == 7) {
+				return fmt.Errorf("pawn on %v", Position{x, y})
+			}
+		}
+	}
+	if kings[White] != 1 {
+		return fmt.Errorf("white must have exactly one king, found %d", kings[White])
+	}
+	if kings[Black] != 1 {
+		return fmt.Errorf("black must have exactly one king, found %d", kings[Black])
+	}
+
+	// The side that just moved can't have left its king in check
+	if IsKingInCheck(g.Board, boolToInt(g.Turn, Black, White)) {
+		return fmt.Errorf("side not to move is in check")
+	}
+
+	// Castling rights need the king and rook on their home squares
+	for _, side := range []struct {
+		right CastlingRights
+		color int
+		rookX int
+	}{
+		{WhiteKingside, White, 7},
+		{WhiteQueenside, White, 0},
+		{BlackKingside, Black, 7},
+		{BlackQueenside, Black, 0},
+	} {
+		if !g.Castling.Has(side.right) {
+			continue
+		}
+		homeY := boolToInt(side.color == White, 7, 0)
+		if g.Board[homeY][4] != side.color*King || g.Board[homeY][side.rookX] != side.color*Rook {
+			return fmt.Errorf("castling right %v needs king and rook on their home squares", side.right)
+		}
+	}
+
+	// The en passant square must be behind a pawn that just moved two squares
+	if target := g.EnPassantTarget; target != nil {
+		mover := boolToInt(g.Turn, Black, White) // The side that made the double push
+		rankY := boolToInt(mover == White, 5, 2)
+		if target.Y != rankY {
+			return fmt.Errorf("en passant square %v is on the wrong rank for the side to move", *target)
+		}
+		pawnY := target.Y - mover  // Where the pushed pawn landed
+		startY := target.Y + mover // Where the pushed pawn started
+		if g.Board[pawnY][target.X] != mover*Pawn {
+			return fmt.Errorf("en passant square %v has no pawn in front of it", *target)
+		}
+		if g.Board[target.Y][target.X] != Empty || g.Board[startY][target.X] != Empty {
+			return fmt.Errorf("en passant square %v is not behind a pawn that just moved two squares", *target)
+		}
+	}
+
+	return nil
+}
+
+// FEN returns the current position in Forsyth-Edwards Notation
+func (g *Game) FEN() string {
+	var sb strings.Builder
+
+	// Piece placement
+	for y := 0; y < 8; y++ {
+		empty := 0
+		for x := 0; x < 8; x++ {
+			piece := g.Board[y][x]
+			if piece == Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(pieceLetter(piece))
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if y < 7 {
+			sb.WriteByte('/')
+		}
+	}
+
+	// Side to move
+	if g.Turn {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	// Castling rights and en passant square
+	sb.WriteString(g.Castling.String())
+	if g.EnPassantTarget != nil {
+		sb.WriteString(" " + g.EnPassantTarget.String())
+	} else {
+		sb.WriteString(" -")
+	}
+
+	// Move counters
+	fmt.Fprintf(&sb, " %d %d", g.HalfmoveClock, g.FullmoveNumber)
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package game
+
+// moveRecord stores everything needed to take back a move
+type moveRecord struct {
+	move            Move
+	lastMove        Move
+	castling        CastlingRights
+	enPassantTarget *Position
+	halfmoveClock   int
+	fullmoveNumber  int
+	state           GameState
+	reason          EndReason
+	hash            uint64
+}
+
+// pushMoveRecord saves the state a move is about to change
+func (g *Game) pushMoveRecord(move Move) {
+	g.undoStack = append(g.undoStack, moveRecord{
+		move:            move,
+		lastMove:        g.LastMove,
+		castling:        g.Castling,
+		enPassantTarget: g.EnPassantTarget,
+		halfmoveClock:   g.HalfmoveClock,
+		fullmoveNumber:  g.FullmoveNumber,
+		state:           g.State,
+		reason:          g.Reason,
+		hash:            g.Hash,
+	})
+}
+
+// Moves returns the moves played so far, oldest first
+func (g *Game) Moves() []Move {
+	moves := make([]Move, len(g.undoStack))
+	for i, record := range g.undoStack {
+		moves[i] = record.move
+	}
+	return moves
+}
+
+// CanUndo checks if there is a move to take back
+func (g *Game) CanUndo() bool {
+	return len(g.undoStack) > 0
+}
+
+// CanRedo checks if there is a taken back move to replay
+func (g *Game) CanRedo() bool {
+	return len(g.redoStack) > 0
+}
+
+// Undo takes back the last move, restoring the board and all game state to
+// what it was before. It returns false if no move has been played.
+func (g *Game) Undo() bool {
+	if !g.CanUndo() {
+		return false
+	}
+
+	g.redoStack = append(g.redoStack, g.takeBack())
+	return true
+}
+
+// takeBack reverses the last move played and returns it
+func (g *Game) takeBack() Move {
+	record := g.undoStack[len(g.undoStack)-1]
+	g.undoStack = g.undoStack[:len(g.undoStack)-1]
+	move := record.move
+	from, to := move.From, move.To
+
+	// Put the moving piece back, turning a promoted piece back into a pawn
+	g.Board[from.Y][from.X] = move.Piece
+	g.Board[to.Y][to.X] = Empty
+
+	// Restore the captured piece
+	if move.Is(FlagEnPassant) {
+		g.Board[from.Y][to.X] = move.Captured
+	} else {
+		g.Board[to.Y][to.X] = move.Captured
+	}
+
+	// Move the castling rook back
+	if move.Is(FlagKingsideCastle) {
+		g.Board[from.Y][7] = g.Board[from.Y][5]
+		g.Board[from.Y][5] = Empty
+	} else if move.Is(FlagQueensideCastle) {
+		g.Board[from.Y][0] = g.Board[from.Y][3]
+		g.Board[from.Y][3] = Empty
+	}
+
+	// Restore the rest of the game state
+	g.LastMove = record.lastMove
+	g.Castling = record.castling
+	g.EnPassantTarget = record.enPassantTarget
+	g.HalfmoveClock = record.halfmoveClock
+	g.FullmoveNumber = record.fullmoveNumber
+	g.State = record.state
+	g.Reason = record.reason
+	g.Hash = record.hash
+	g.Turn = !g.Turn
+	if len(g.PositionHistory) > 0 {
+		g.PositionHistory = g.PositionHistory[:len(g.PositionHistory)-1]
+	}
+
+	return move
+}
+
+// Redo replays the most recently taken back move. It returns false if there is
+// nothing to redo.
+func (g *Game) Redo() bool {
+	if !g.CanRedo() {
+		return false
+	}
+
+	move := g.redoStack[len(g.redoStack)-1]
+	g.redoStack = g.redoStack[:len(g.redoStack)-1]
+	g.playMove(move)
+	g.UpdateGameState()
+	return true
+}
@@ -0,0 +1,98 @@
+package game
+
+// materialCount summarizes one side's non-king material
+type materialCount struct {
+	pawns, knights, rooks, queens int
+	lightBishops, darkBishops     int
+}
+
+// countMaterial tallies the pieces of the specified color
+func countMaterial(board [8][8]int, color int) materialCount {
+	var m materialCount
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := board[y][x]
+			if piece == Empty || sign(piece) != color {
+				continue
+			}
+			switch abs(piece) {
+			case Pawn:
+				m.pawns++
+			case Knight:
+				m.knights++
+			case Bishop:
+				if (x+y)%2 == 0 {
+					m.lightBishops++
+				} else {
+					m.darkBishops++
+				}
+			case Rook:
+				m.rooks++
+			case Queen:
+				m.queens++
+			}
+		}
+	}
+	return m
+}
+
+// HasMatingMaterial reports whether the specified color could checkmate by any
+// sequence of legal moves, however unlikely. A lone king, a single knight
+// against a bare king, and bishops that all stand on one square color against
+// nothing but bishops on that same color can never deliver mate.
+func HasMatingMaterial(board [8][8]int, color int) bool {
+	own := countMaterial(board, color)
+	opponent := countMaterial(board, -color)
+
+	if own.pawns > 0 || own.rooks > 0 || own.queens > 0 {
+		return true
+	}
+
+	bishops := own.lightBishops + own.darkBishops
+	switch {
+	case own.knights == 0 && bishops == 0:
+		// Only the king is left
+		return false
+	case own.knights > 1 || (own.knights > 0 && bishops > 0):
+		return true
+	case own.knights == 1:
+		// A knight can only mate if the other side has pieces to block its own king
+		return opponent != materialCount{}
+	}
+
+	// Only bishops are left
+	if own.lightBishops > 0 && own.darkBishops > 0 {
+		return true
+	}
+	if opponent.pawns > 0 || opponent.knights > 0 || opponent.rooks > 0 || opponent.queens > 0 {
+		return true
+	}
+	// Opposing bishops on the other square color can block the king in a corner
+	if own.lightBishops > 0 {
+		return opponent.darkBishops > 0
+	}
+	return opponent.lightBishops > 0
+}
+
+// IsInsufficientMaterial reports whether neither side can ever checkmate
+func IsInsufficientMaterial(board [8][8]int) bool {
+	return !HasMatingMaterial(board, White) && !HasMatingMaterial(board, Black)
+}
+
+// TimeForfeit ends the game because the specified color ran out of time. The
+// opponent wins unless they could never checkmate, in which case it is a draw.
+func (g *Game) TimeForfeit(color int) {
+	if g.State != Playing {
+		return
+	}
+
+	g.Reason = Timeout
+	switch {
+	case !HasMatingMaterial(g.Board, -color):
+		g.State = Draw
+	case color == White:
+		g.State = BlackWins
+	default:
+		g.State = WhiteWins
+	}
+}
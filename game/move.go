@@ -0,0 +1,80 @@
+package game
+
+// MoveFlag marks the special properties of a move
+type MoveFlag int
+
+const (
+	FlagCapture         MoveFlag = 1 << iota // Takes an opposing piece
+	FlagEnPassant                            // Pawn capture onto the en passant square
+	FlagDoublePush                           // Pawn moves two squares from its starting rank
+	FlagKingsideCastle                       // King moves two squares toward the h-file rook
+	FlagQueensideCastle                      // King moves two squares toward the a-file rook
+	FlagPromotion                            // Pawn reaches the last rank
+)
+
+// Move describes a single move together with everything needed to play it
+type Move struct {
+	From, To  Position
+	Piece     int // Moving piece, signed by color
+	Captured  int // Captured piece, signed by color, or Empty
+	Promotion int // Piece type the pawn becomes, or Empty
+	Flags     MoveFlag
+}
+
+// Is checks if all of the given flags are set on the move
+func (m Move) Is(flags MoveFlag) bool {
+	return m.Flags&flags == flags
+}
+
+// IsCapture checks if the move takes a piece, including en passant
+func (m Move) IsCapture() bool {
+	return m.Is(FlagCapture)
+}
+
+// IsCastle checks if the move castles on either side
+func (m Move) IsCastle() bool {
+	return m.Flags&(FlagKingsideCastle|FlagQueensideCastle) != 0
+}
+
+// IsPromotion checks if the move promotes a pawn
+func (m Move) IsPromotion() bool {
+	return m.Is(FlagPromotion)
+}
+
+// appendPawnMove adds a pawn move, expanding moves onto the last rank into one
+// move per promotion piece
+func appendPawnMove(moves []Move, move Move) []Move {
+	if move.To.Y != 0 && move.To.Y != 7 {
+		return append(moves, move)
+	}
+
+	move.Flags |= FlagPromotion
+	for _, piece := range PromotionPieces {
+		move.Promotion = piece
+		moves = append(moves, move)
+	}
+	return moves
+}
+
+// LegalMoves returns every legal move for the side to move
+func (g *Game) LegalMoves() []Move {
+	p := newBitPosition(g.Board, g)
+	return p.legalMoves(make([]Move, 0), p.turn, p.colors[colorIndex(p.turn)])
+}
+
+// FindMove looks up the legal move between two squares. The piece on from
+// must belong to the side to move. For promotions, promotion selects the
+// piece; it is ignored for all other moves.
+func (g *Game) FindMove(from, to Position, promotion int) (Move, bool) {
+	if !IsValidPosition(from) || !IsValidPosition(to) {
+		return Move{}, false
+	}
+	p := newBitPosition(g.Board, g)
+	own := p.colors[colorIndex(p.turn)] & squareBit(squareIndex(from))
+	for _, move := range p.legalMoves(nil, p.turn, own) {
+		if move.To == to && (!move.IsPromotion() || move.Promotion == promotion) {
+			return move, true
+		}
+	}
+	return Move{}, false
+}
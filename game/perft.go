@@ -0,0 +1,59 @@
+package game
+
+import "sort"
+
+// DivideResult is the number of leaf nodes below one root move
+type DivideResult struct {
+	Move  Move
+	Nodes int64
+}
+
+// Perft counts the positions reached by every sequence of legal moves depth
+// plies long. Comparing the counts with published figures is the standard way
+// to check a move generator.
+func (g *Game) Perft(depth int) int64 {
+	if depth <= 0 {
+		return 1
+	}
+	return newBitPosition(g.Board, g).perft(depth)
+}
+
+// Divide splits the perft count by root move, sorted by UCI notation, to
+// narrow down which move a wrong count comes from
+func (g *Game) Divide(depth int) []DivideResult {
+	p := newBitPosition(g.Board, g)
+	moves := p.legalMoves(nil, p.turn, p.colors[colorIndex(p.turn)])
+
+	results := make([]DivideResult, 0, len(moves))
+	for _, move := range moves {
+		nodes := int64(1)
+		if depth > 1 {
+			undo := p.makeMove(move)
+			nodes = p.perft(depth - 1)
+			p.unmakeMove(move, undo)
+		}
+		results = append(results, DivideResult{Move: move, Nodes: nodes})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Move.UCI() < results[j].Move.UCI()
+	})
+	return results
+}
+
+// perft counts the leaf nodes below the position. The last ply is counted
+// without being played.
+func (p *bitPosition) perft(depth int) int64 {
+	moves := p.legalMoves(nil, p.turn, p.colors[colorIndex(p.turn)])
+	if depth == 1 {
+		return int64(len(moves))
+	}
+
+	var nodes int64
+	for _, move := range moves {
+		undo := p.makeMove(move)
+		nodes += p.perft(depth - 1)
+		p.unmakeMove(move, undo)
+	}
+	return nodes
+}
@@ -0,0 +1,179 @@
+package game
+
+import "testing"
+
+// perftPositions are standard move generator test positions with their
+// published node counts, indexed by depth minus one
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []int64
+}{
+	{
+		name:  "start",
+		fen:   StartFEN,
+		nodes: []int64{20, 400, 8902, 197281, 4865609},
+	},
+	{
+		name:  "kiwipete",
+		fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		nodes: []int64{48, 2039, 97862, 4085603},
+	},
+	{
+		name:  "endgame",
+		fen:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		nodes: []int64{14, 191, 2812, 43238, 674624},
+	},
+	{
+		name:  "promotions",
+		fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		nodes: []int64{6, 264, 9467, 422333},
+	},
+	{
+		name:  "promotions mirrored",
+		fen:   "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		nodes: []int64{6, 264, 9467, 422333},
+	},
+	{
+		name:  "discovered checks",
+		fen:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		nodes: []int64{44, 1486, 62379, 2103487},
+	},
+	{
+		name:  "middlegame",
+		fen:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		nodes: []int64{46, 2079, 89890, 3894594},
+	},
+	{
+		name:  "illegal en passant",
+		fen:   "3k4/3p4/8/K1P4r/8/8/8/8 b - - 0 1",
+		nodes: []int64{18, 92, 1670, 10138, 185429, 1134888},
+	},
+	{
+		name:  "en passant gives check",
+		fen:   "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1",
+		nodes: []int64{15, 126, 1928, 13931, 206379, 1440467},
+	},
+	{
+		name:  "castling gives check",
+		fen:   "5k2/8/8/8/8/8/8/4K2R w K - 0 1",
+		nodes: []int64{15, 66, 1198, 6399, 120330, 661072},
+	},
+	{
+		name:  "castling rights",
+		fen:   "r3k2r/1b4bq/8/8/8/8/7B/R3K2R w KQkq - 0 1",
+		nodes: []int64{26, 1141, 27826, 1274206},
+	},
+	{
+		name:  "castling prevented",
+		fen:   "r3k2r/8/3Q4/8/8/5q2/8/R3K2R b KQkq - 0 1",
+		nodes: []int64{44, 1494, 50509, 1720476},
+	},
+	{
+		name:  "promote out of check",
+		fen:   "2K2r2/4P3/8/8/8/8/8/3k4 w - - 0 1",
+		nodes: []int64{11, 133, 1442, 19174, 266199, 3821001},
+	},
+	{
+		name:  "underpromote to give check",
+		fen:   "8/P1k5/K7/8/8/8/8/8 w - - 0 1",
+		nodes: []int64{6, 27, 273, 1329, 18135, 92683},
+	},
+	{
+		name:  "self stalemate",
+		fen:   "K1k5/8/P7/8/8/8/8/8 w - - 0 1",
+		nodes: []int64{2, 6, 13, 63, 382, 2217},
+	},
+	{
+		name:  "stalemate and checkmate",
+		fen:   "8/8/2k5/5q2/5n2/8/5K2/8 b - - 0 1",
+		nodes: []int64{37, 183, 6559, 23527},
+	},
+}
+
+// shortPerftNodes caps the node count of the positions run in short mode
+const shortPerftNodes = 100000
+
+func TestPerft(t *testing.T) {
+	for _, tt := range perftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.nodes {
+				if testing.Short() && want > shortPerftNodes {
+					break
+				}
+				if got := g.Perft(i + 1); got != want {
+					t.Errorf("depth %d: got %d nodes, want %d", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestPerftGame walks the tree through Game.MakeMove and Game.Undo instead of
+// the bitboard position, checking that the two agree and that undo restores
+// everything it should
+func TestPerftGame(t *testing.T) {
+	for _, tt := range perftPositions {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fen := g.FEN()
+			if got, want := gamePerft(t, g, 3), tt.nodes[2]; got != want {
+				t.Errorf("depth 3: got %d nodes, want %d", got, want)
+			}
+			if g.FEN() != fen {
+				t.Errorf("position after undoing everything is %s, want %s", g.FEN(), fen)
+			}
+		})
+	}
+}
+
+// gamePerft counts leaf nodes by playing and taking back every move on the game
+func gamePerft(t *testing.T, g *Game, depth int) int64 {
+	if depth == 0 {
+		return 1
+	}
+
+	var nodes int64
+	for _, move := range g.LegalMoves() {
+		fen, hash := g.FEN(), g.Hash
+		g.MakeMove(move)
+		if g.Hash != g.ComputeHash() {
+			t.Fatalf("hash after %s from %s does not match the position", move.UCI(), fen)
+		}
+		nodes += gamePerft(t, g, depth-1)
+		g.Undo()
+		if g.FEN() != fen || g.Hash != hash {
+			t.Fatalf("undoing %s gave %s, want %s", move.UCI(), g.FEN(), fen)
+		}
+	}
+	return nodes
+}
+
+func TestDivide(t *testing.T) {
+	g, err := ParseFEN(perftPositions[1].fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := g.Divide(2)
+	if len(results) != 48 {
+		t.Fatalf("got %d root moves, want 48", len(results))
+	}
+	var total int64
+	for i, r := range results {
+		total += r.Nodes
+		if i > 0 && results[i-1].Move.UCI() >= r.Move.UCI() {
+			t.Errorf("results not sorted: %s before %s", results[i-1].Move.UCI(), r.Move.UCI())
+		}
+	}
+	if total != 2039 {
+		t.Errorf("nodes add up to %d, want 2039", total)
+	}
+}
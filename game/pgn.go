@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// pgnLineLength is the maximum length of a movetext line in exported PGN
+const pgnLineLength = 80
+
+// sevenTagRoster lists the tags every PGN game has, in their required order
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// startingGame returns a new game set up at the position this game started
+// from. InitialFEN is an exported field, so it may not parse.
+func (g *Game) startingGame() (*Game, error) {
+	if g.InitialFEN == "" {
+		return NewGame(), nil
+	}
+	start, err := ParseFEN(g.InitialFEN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid initial position: %v", err)
+	}
+	return start, nil
+}
+
+// SANMoves returns the moves played so far in Standard Algebraic Notation. It
+// fails if the game's initial position is invalid.
+func (g *Game) SANMoves() ([]string, error) {
+	replay, err := g.startingGame()
+	if err != nil {
+		return nil, err
+	}
+	moves := g.Moves()
+	sans := make([]string, len(moves))
+	for i, move := range moves {
+		sans[i] = replay.SAN(move)
+		replay.playMove(move)
+	}
+	return sans, nil
+}
+
+// PGN exports the game in Portable Game Notation. The Seven Tag Roster is
+// always written, using "?" for unknown values, and the Result tag follows
+// State. Games that did not start from the standard position get SetUp and FEN
+// tags. Movetext lines are wrapped at 80 characters. It fails if the game's
+// initial position is invalid.
+func (g *Game) PGN() (string, error) {
+	start, err := g.startingGame()
+	if err != nil {
+		return "", err
+	}
+	sans, err := g.SANMoves()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	// Seven Tag Roster, then the setup tags, then everything else alphabetically
+	result := g.State.Result()
+	for _, name := range sevenTagRoster {
+		value := g.Tags[name]
+		switch {
+		case name == "Result":
+			value = result
+		case value != "":
+		case name == "Date":
+			value = "????.??.??"
+		default:
+			value = "?"
+		}
+		writePGNTag(&sb, name, value)
+	}
+	if g.InitialFEN != "" {
+		writePGNTag(&sb, "SetUp", "1")
+		writePGNTag(&sb, "FEN", g.InitialFEN)
+	}
+	var extra []string
+	for name := range g.Tags {
+		if !isReservedTag(name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		writePGNTag(&sb, name, g.Tags[name])
+	}
+	sb.WriteByte('\n')
+
+	// Movetext
+	number, white := start.FullmoveNumber, start.Turn
+	var tokens []string
+	for i, san := range sans {
+		// Keep move numbers on the same line as their move
+		switch {
+		case white:
+			san = fmt.Sprintf("%d. %s", number, san)
+		case i == 0:
+			san = fmt.Sprintf("%d... %s", number, san)
+		}
+		tokens = append(tokens, san)
+		if !white {
+			number++
+		}
+		white = !white
+	}
+	tokens = append(tokens, result)
+
+	lineLength := 0
+	for _, token := range tokens {
+		if lineLength > 0 && lineLength+1+len(token) > pgnLineLength {
+			sb.WriteByte('\n')
+			lineLength = 0
+		}
+		if lineLength > 0 {
+			sb.WriteByte(' ')
+			lineLength++
+		}
+		sb.WriteString(token)
+		lineLength += len(token)
+	}
+	sb.WriteByte('\n')
+
+	return sb.String(), nil
+}
+
+// isReservedTag checks if a tag is written by PGN itself rather than from Tags
+func isReservedTag(name string) bool {
+	if name == "SetUp" || name == "FEN" {
+		return true
+	}
+	for _, tag := range sevenTagRoster {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writePGNTag writes a tag pair, escaping quotes and backslashes in the value
+func writePGNTag(sb *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, value)
+}
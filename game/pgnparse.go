@@ -0,0 +1,521 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PGNError reports a problem at a specific place in PGN text. Lines and
+// columns start at 1, and columns count characters rather than bytes.
+type PGNError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *PGNError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// PGNGame is a game read from PGN: its tag pairs and its tree of moves
+type PGNGame struct {
+	Tags   map[string]string
+	Root   *PGNNode // Starting position; its children are the first moves
+	Result string   // Game termination marker, such as "1-0" or "*"
+}
+
+// PGNNode is a position in the game tree, reached by playing Move from its
+// parent. Children[0] continues the line; any further children are variations.
+type PGNNode struct {
+	SAN            string   // Move as written in the PGN
+	Move           Move     // Move resolved against the position before it
+	CommentsBefore []string // Comments placed before the move, at the start of a variation
+	Comments       []string // Comments placed after the move
+	NAGs           []int    // Numeric annotation glyphs, including ones written as "!" or "?"
+	Parent         *PGNNode
+	Children       []*PGNNode
+}
+
+// suffixNAGs maps move suffix annotations to their numeric annotation glyphs
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
+
+// Mainline returns the nodes along the main line, first move first
+func (pg *PGNGame) Mainline() []*PGNNode {
+	var nodes []*PGNNode
+	for node := pg.Root; len(node.Children) > 0; {
+		node = node.Children[0]
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Game replays the main line onto a new game, starting from the FEN tag if
+// there is one. The tags are copied to the game. Since Game.State follows the
+// rules on the board, a result such as a resignation is only kept in the
+// Result tag.
+func (pg *PGNGame) Game() (*Game, error) {
+	g, err := pgnStartingGame(pg.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, node := range pg.Mainline() {
+		move, ok := g.FindMove(node.Move.From, node.Move.To, node.Move.Promotion)
+		if !ok {
+			return nil, fmt.Errorf("ply %d: illegal move %q", i+1, node.SAN)
+		}
+		g.MakeMove(move)
+		g.UpdateGameState()
+	}
+
+	g.Tags = make(map[string]string, len(pg.Tags))
+	for name, value := range pg.Tags {
+		g.Tags[name] = value
+	}
+	return g, nil
+}
+
+// pgnStartingGame sets up the starting position described by the tags
+func pgnStartingGame(tags map[string]string) (*Game, error) {
+	fen, ok := tags["FEN"]
+	if !ok {
+		return NewGame(), nil
+	}
+	return ParseFEN(fen)
+}
+
+// ParsePGN parses a single game in Portable Game Notation, including
+// recursive variations, brace and semicolon comments and numeric annotation
+// glyphs. Every move is checked for legality. Errors are *PGNError values
+// giving the line and column of the problem.
+func ParsePGN(text string) (*PGNGame, error) {
+	p := newPGNParser(text, 1)
+	pg, err := p.parseGame()
+	if err != nil {
+		return nil, err
+	}
+	if pg == nil {
+		return nil, &PGNError{Line: 1, Column: 1, Msg: "no game found"}
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok, "unexpected %s after the end of the game", tok)
+	}
+	return pg, nil
+}
+
+// pgnTokenKind identifies the kind of a PGN token
+type pgnTokenKind int
+
+const (
+	tokenEOF pgnTokenKind = iota
+	tokenSymbol
+	tokenString
+	tokenComment
+	tokenNAG
+	tokenSuffix // Move annotation such as "!" or "?!"
+	tokenPeriod
+	tokenOpenBracket
+	tokenCloseBracket
+	tokenOpenParen
+	tokenCloseParen
+)
+
+// pgnToken is a lexical token with the position where it starts
+type pgnToken struct {
+	kind         pgnTokenKind
+	text         string
+	line, column int
+}
+
+func (t pgnToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// pgnLexer splits PGN text into tokens, tracking lines and columns
+type pgnLexer struct {
+	src          string
+	pos          int
+	line, column int
+}
+
+// advance consumes one byte, updating the line and column
+func (l *pgnLexer) advance() {
+	c := l.src[l.pos]
+	l.pos++
+	switch {
+	case c == '\n':
+		l.line++
+		l.column = 1
+	case c&0xC0 != 0x80: // Don't count UTF-8 continuation bytes
+		l.column++
+	}
+}
+
+// isSymbolStart checks if a byte can start a symbol token
+func isSymbolStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '*'
+}
+
+// isSymbolContinue checks if a byte can continue a symbol token
+func isSymbolContinue(c byte) bool {
+	return (isSymbolStart(c) && c != '*') || strings.IndexByte("_+#=:-/", c) >= 0
+}
+
+// next returns the next token
+func (l *pgnLexer) next() (pgnToken, error) {
+	// Skip whitespace and escaped lines
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '%' && l.column == 1 {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance()
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			break
+		}
+		l.advance()
+	}
+
+	tok := pgnToken{line: l.line, column: l.column}
+	if l.pos >= len(l.src) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case c == '[' || c == ']' || c == '(' || c == ')' || c == '.':
+		tok.kind = map[byte]pgnTokenKind{
+			'[': tokenOpenBracket,
+			']': tokenCloseBracket,
+			'(': tokenOpenParen,
+			')': tokenCloseParen,
+			'.': tokenPeriod,
+		}[c]
+		l.advance()
+		tok.text = string(c)
+
+	case c == '"':
+		tok.kind = tokenString
+		l.advance()
+		var sb strings.Builder
+		for {
+			if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+				return tok, &PGNError{tok.line, tok.column, "unterminated string"}
+			}
+			c := l.src[l.pos]
+			l.advance()
+			if c == '"' {
+				break
+			}
+			if c == '\\' && l.pos < len(l.src) && (l.src[l.pos] == '"' || l.src[l.pos] == '\\') {
+				c = l.src[l.pos]
+				l.advance()
+			}
+			sb.WriteByte(c)
+		}
+		tok.text = sb.String()
+
+	case c == '{':
+		tok.kind = tokenComment
+		l.advance()
+		for l.pos < len(l.src) && l.src[l.pos] != '}' {
+			l.advance()
+		}
+		if l.pos >= len(l.src) {
+			return tok, &PGNError{tok.line, tok.column, "unterminated comment"}
+		}
+		tok.text = strings.TrimSpace(l.src[start+1 : l.pos])
+		l.advance()
+
+	case c == ';':
+		tok.kind = tokenComment
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.advance()
+		}
+		tok.text = strings.TrimSpace(l.src[start+1 : l.pos])
+
+	case c == '$':
+		tok.kind = tokenNAG
+		l.advance()
+		for l.pos < len(l.src) && l.src[l.pos] >= '0' && l.src[l.pos] <= '9' {
+			l.advance()
+		}
+		tok.text = l.src[start:l.pos]
+		if len(tok.text) == 1 {
+			return tok, &PGNError{tok.line, tok.column, "missing number after \"$\""}
+		}
+
+	case c == '!' || c == '?':
+		tok.kind = tokenSuffix
+		for l.pos < len(l.src) && (l.src[l.pos] == '!' || l.src[l.pos] == '?') {
+			l.advance()
+		}
+		tok.text = l.src[start:l.pos]
+
+	case isSymbolStart(c):
+		tok.kind = tokenSymbol
+		l.advance()
+		if c != '*' {
+			for l.pos < len(l.src) && isSymbolContinue(l.src[l.pos]) {
+				l.advance()
+			}
+		}
+		tok.text = l.src[start:l.pos]
+
+	default:
+		return tok, &PGNError{tok.line, tok.column, fmt.Sprintf("unexpected character %q", c)}
+	}
+
+	return tok, nil
+}
+
+// pgnParser builds games from the tokens of a lexer, with one token of lookahead
+type pgnParser struct {
+	lex    pgnLexer
+	peeked *pgnToken
+	err    error
+
+	tagValues map[string]pgnToken // Value token of each tag, to locate errors in it
+}
+
+// newPGNParser creates a parser for text whose first line has the given number
+func newPGNParser(text string, firstLine int) *pgnParser {
+	return &pgnParser{lex: pgnLexer{src: text, line: firstLine, column: 1}}
+}
+
+// peek returns the next token without consuming it. Lexical errors are
+// remembered and reported by the following call to next.
+func (p *pgnParser) peek() pgnToken {
+	if p.peeked == nil {
+		tok, err := p.lex.next()
+		if err != nil {
+			p.err = err
+			tok.kind = tokenEOF
+		}
+		p.peeked = &tok
+	}
+	return *p.peeked
+}
+
+// next consumes and returns the next token
+func (p *pgnParser) next() (pgnToken, error) {
+	tok := p.peek()
+	p.peeked = nil
+	if p.err != nil {
+		return tok, p.err
+	}
+	return tok, nil
+}
+
+// errorAt creates an error located at a token
+func (p *pgnParser) errorAt(tok pgnToken, format string, args ...any) error {
+	return &PGNError{tok.line, tok.column, fmt.Sprintf(format, args...)}
+}
+
+// isResult checks if a symbol is a game termination marker
+func isResult(s string) bool {
+	return s == "1-0" || s == "0-1" || s == "1/2-1/2" || s == "*"
+}
+
+// parseGame reads one game: its tag pairs followed by its movetext. It
+// returns nil without an error if the input holds no more games.
+func (p *pgnParser) parseGame() (*PGNGame, error) {
+	tags, err := p.parseTags()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 && p.peek().kind == tokenEOF && p.err == nil {
+		return nil, nil
+	}
+
+	pg := &PGNGame{Tags: tags, Root: &PGNNode{}}
+	if err := p.parseMovetext(pg); err != nil {
+		return nil, err
+	}
+	return pg, nil
+}
+
+// parseTags reads the tag pair section
+func (p *pgnParser) parseTags() (map[string]string, error) {
+	tags := make(map[string]string)
+	p.tagValues = make(map[string]pgnToken)
+	for p.peek().kind == tokenOpenBracket {
+		p.next()
+		name, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if name.kind != tokenSymbol {
+			return nil, p.errorAt(name, "expected tag name, found %s", name)
+		}
+		value, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if value.kind != tokenString {
+			return nil, p.errorAt(value, "expected quoted value for tag %s, found %s", name.text, value)
+		}
+		closing, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if closing.kind != tokenCloseBracket {
+			return nil, p.errorAt(closing, "expected \"]\" after tag %s, found %s", name.text, closing)
+		}
+		tags[name.text] = value.text
+		p.tagValues[name.text] = value
+	}
+	return tags, nil
+}
+
+// parseMovetext reads moves, variations, comments and annotations up to and
+// including the game termination marker. Each move is resolved against the
+// position it is played in, so the game is replayed alongside the tree.
+func (p *pgnParser) parseMovetext(pg *PGNGame) error {
+	g, err := pgnStartingGame(pg.Tags)
+	if err != nil {
+		// Only the FEN tag can be invalid
+		return p.errorAt(p.tagValues["FEN"], "invalid FEN tag: %v", err)
+	}
+
+	node := pg.Root
+	var variations []*PGNNode // Main line node to return to at the end of each open variation
+	var pendingComments []string
+	atLineStart := true // No move played yet in the game or the current variation
+
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+
+		switch tok.kind {
+		case tokenEOF, tokenOpenBracket:
+			// Missing termination marker: end at the next game or the end of input
+			if len(variations) > 0 {
+				return p.errorAt(tok, "unterminated variation")
+			}
+			if tok.kind == tokenOpenBracket {
+				p.peeked = &tok // Leave the tag for the next game
+			}
+			node.Comments = append(node.Comments, pendingComments...)
+			pg.Result = pg.Tags["Result"]
+			if !isResult(pg.Result) {
+				pg.Result = "*"
+			}
+			return nil
+
+		case tokenPeriod:
+			// Part of a move number
+
+		case tokenComment:
+			if atLineStart {
+				pendingComments = append(pendingComments, tok.text)
+			} else {
+				node.Comments = append(node.Comments, tok.text)
+			}
+
+		case tokenNAG:
+			nag, err := strconv.Atoi(tok.text[1:])
+			if err != nil || nag > 255 {
+				return p.errorAt(tok, "invalid numeric annotation glyph %s", tok)
+			}
+			if atLineStart {
+				// At the start of a variation, node is still the parent's
+				// move, which the glyph must not annotate
+				return p.errorAt(tok, "annotation glyph %s before the first move", tok)
+			}
+			node.NAGs = append(node.NAGs, nag)
+
+		case tokenSuffix:
+			nag, ok := suffixNAGs[tok.text]
+			if !ok {
+				return p.errorAt(tok, "invalid move annotation %s", tok)
+			}
+			if atLineStart {
+				return p.errorAt(tok, "move annotation %s before the first move", tok)
+			}
+			node.NAGs = append(node.NAGs, nag)
+
+		case tokenOpenParen:
+			// A variation replaces the move just played
+			if node == pg.Root {
+				return p.errorAt(tok, "variation before the first move")
+			}
+			variations = append(variations, node)
+			g.takeBack()
+			node = node.Parent
+			atLineStart = true
+
+		case tokenCloseParen:
+			if len(variations) == 0 {
+				return p.errorAt(tok, "\")\" without a matching \"(\"")
+			}
+			node.Comments = append(node.Comments, pendingComments...)
+			pendingComments = nil
+			resume := variations[len(variations)-1]
+			variations = variations[:len(variations)-1]
+			for len(g.undoStack) > pgnPly(resume.Parent) {
+				g.takeBack()
+			}
+			g.playMove(resume.Move)
+			node = resume
+			atLineStart = false
+
+		case tokenSymbol:
+			if isResult(tok.text) {
+				if len(variations) > 0 {
+					return p.errorAt(tok, "game result %s inside a variation", tok)
+				}
+				node.Comments = append(node.Comments, pendingComments...)
+				pg.Result = tok.text
+				return nil
+			}
+			if strings.Trim(tok.text, "0123456789") == "" {
+				continue // Move number
+			}
+
+			move, err := g.ParseSAN(tok.text)
+			if err != nil {
+				return p.errorAt(tok, "%v", err)
+			}
+			child := &PGNNode{
+				SAN:            tok.text,
+				Move:           move,
+				CommentsBefore: pendingComments,
+				Parent:         node,
+			}
+			pendingComments = nil
+			atLineStart = false
+			node.Children = append(node.Children, child)
+			g.playMove(move)
+			node = child
+
+		default:
+			return p.errorAt(tok, "unexpected %s in movetext", tok)
+		}
+	}
+}
+
+// pgnPly returns the number of moves played to reach a node
+func pgnPly(node *PGNNode) int {
+	ply := 0
+	for ; node.Parent != nil; node = node.Parent {
+		ply++
+	}
+	return ply
+}
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePGNAnnotationPlacement(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		line   int
+		column int
+	}{
+		{"glyph before the first move", "$1 1. e4 *", 1, 1},
+		{"suffix before the first move", "!! 1. e4 *", 1, 1},
+		{"glyph at the start of a variation", "1. e4 ( $1 1. d4 ) e5 *", 1, 9},
+		{"glyph after a comment at the start of a variation", "1. e4 ( {idea} $2 1. d4 ) *", 1, 16},
+		{"suffix at the start of a variation", "1. e4 ( ?! 1. d4 ) *", 1, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePGN(tt.text)
+			var pgnErr *PGNError
+			if !errors.As(err, &pgnErr) {
+				t.Fatalf("ParsePGN(%q) error = %v, want a *PGNError", tt.text, err)
+			}
+			if pgnErr.Line != tt.line || pgnErr.Column != tt.column {
+				t.Errorf("ParsePGN(%q) error at %d:%d, want %d:%d (%v)", tt.text, pgnErr.Line, pgnErr.Column, tt.line, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestParsePGNAnnotatesVariationMoves(t *testing.T) {
+	pg, err := ParsePGN("1. e4 $1 ( 1. d4 $2 ) e5 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e4 := pg.Root.Children[0]
+	if len(e4.NAGs) != 1 || e4.NAGs[0] != 1 {
+		t.Errorf("e4 NAGs = %v, want [1]", e4.NAGs)
+	}
+	if len(pg.Root.Children) != 2 {
+		t.Fatalf("root has %d children, want 2", len(pg.Root.Children))
+	}
+	d4 := pg.Root.Children[1]
+	if d4.SAN != "d4" || len(d4.NAGs) != 1 || d4.NAGs[0] != 2 {
+		t.Errorf("variation move %s NAGs = %v, want d4 [2]", d4.SAN, d4.NAGs)
+	}
+}
+
+func TestParsePGNInvalidFENTag(t *testing.T) {
+	text := "[Event \"Bad setup\"]\n[SetUp \"1\"]\n[FEN \"8/8/8 w - - 0 1\"]\n[Result \"*\"]\n\n\n 1. e4 *\n"
+	_, err := ParsePGN(text)
+	var pgnErr *PGNError
+	if !errors.As(err, &pgnErr) {
+		t.Fatalf("ParsePGN error = %v, want a *PGNError", err)
+	}
+	if pgnErr.Line != 3 || pgnErr.Column != 6 {
+		t.Errorf("error at %d:%d, want 3:6 where the FEN value starts (%v)", pgnErr.Line, pgnErr.Column, err)
+	}
+}
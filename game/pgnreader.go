@@ -0,0 +1,265 @@
+package game
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxPGNGameSize is the largest game text a PGNReader accepts by default
+const DefaultMaxPGNGameSize = 1 << 20
+
+// PGNReader reads games one at a time from a stream holding any number of
+// PGN games. Only the text of the current game is kept in memory, so archives
+// of any size can be processed.
+//
+// A malformed game does not stop the reader: Next reports it and the following
+// call moves on to the next game.
+type PGNReader struct {
+	// Filter, if set, is called with the tags of every game. Games it rejects
+	// are skipped without parsing their moves.
+	Filter func(tags map[string]string) bool
+
+	// MaxGameSize limits the size in bytes of a single game's text. Larger
+	// games are skipped and reported as errors.
+	MaxGameSize int
+
+	r           *bufio.Reader
+	line        int    // Number of lines read so far
+	nextLine    string // First line of the next game, already read
+	nextSize    int    // Full length of nextLine before it was cut short
+	nextComment bool   // Whether nextLine leaves a brace comment open
+	hasNext     bool
+}
+
+// NewPGNReader creates a reader for the PGN games in r
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{
+		MaxGameSize: DefaultMaxPGNGameSize,
+		r:           bufio.NewReaderSize(r, 64*1024),
+	}
+}
+
+// Next returns the next game that passes the filter. It returns io.EOF once
+// the input is exhausted. If a game is malformed, Next returns a *PGNError
+// with line numbers counted from the start of the stream; the reader can keep
+// going after it. Errors reading the underlying stream are returned as is.
+func (r *PGNReader) Next() (*PGNGame, error) {
+	for {
+		text, firstLine, err := r.readGameText()
+		if err != nil {
+			return nil, err
+		}
+
+		p := newPGNParser(text, firstLine)
+		tags, err := p.parseTags()
+		if err != nil {
+			return nil, err
+		}
+		if r.Filter != nil && !r.Filter(tags) {
+			continue
+		}
+
+		pg := &PGNGame{Tags: tags, Root: &PGNNode{}}
+		if err := p.parseMovetext(pg); err != nil {
+			return nil, err
+		}
+		if tok := p.peek(); tok.kind != tokenEOF {
+			return nil, p.errorAt(tok, "unexpected %s after the end of the game", tok)
+		}
+		return pg, nil
+	}
+}
+
+// readLine returns the next line, including its newline, or io.EOF. The line
+// is read in chunks and at most MaxGameSize bytes of it are kept, since a
+// longer line makes its game too large anyway; the rest is dropped. It also
+// returns the full length of the line and, should it be movetext, whether a
+// brace comment is open at its end given whether one was open at its start.
+func (r *PGNReader) readLine(inComment bool) (line string, size int, commentOpen bool, err error) {
+	if r.hasNext {
+		r.hasNext = false
+		return r.nextLine, r.nextSize, r.nextComment, nil
+	}
+
+	var kept []byte
+	comment := pgnCommentState{open: inComment}
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		size += len(chunk)
+		if room := r.MaxGameSize - len(kept); room > 0 {
+			kept = append(kept, chunk[:min(len(chunk), room)]...)
+		}
+		comment.scan(chunk)
+		if err == bufio.ErrBufferFull {
+			continue // No newline yet
+		}
+		if err == io.EOF && size > 0 {
+			err = nil // Last line without a newline
+		}
+		if err != nil {
+			return "", 0, false, err
+		}
+		break
+	}
+
+	r.line++
+	line = string(kept)
+	if r.line == 1 {
+		trimmed := strings.TrimPrefix(line, "\ufeff") // Byte order mark
+		size -= len(line) - len(trimmed)
+		line = trimmed
+	}
+	return line, size, comment.open, nil
+}
+
+// readGameText collects the lines of the next game. A game ends where a tag
+// line follows its movetext, or at the end of the input. A game missing its
+// movetext also ends where a tag line follows a blank line after its tags, or
+// repeats one of its tag names, so that it can't swallow the next game. It
+// returns the text and the number of its first line.
+func (r *PGNReader) readGameText() (string, int, error) {
+	var sb strings.Builder
+	firstLine := 0
+	inMovetext := false
+	inComment := false // Inside a brace comment that spans lines
+	tooLarge := false
+	tags := make(map[string]bool) // Names of the tags read so far
+	tagsEnded := false            // A blank line followed the tags
+
+	for {
+		line, size, commentOpen, err := r.readLine(inComment)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if firstLine == 0 {
+			if trimmed == "" {
+				continue // Blank lines between games
+			}
+			firstLine = r.line
+		}
+
+		if !inComment && strings.HasPrefix(trimmed, "[") {
+			names := pgnTagNames(trimmed)
+			if inMovetext || tagsEnded || slices.ContainsFunc(names, func(name string) bool { return tags[name] }) {
+				// This tag starts the next game
+				r.nextLine, r.nextSize, r.nextComment = line, size, commentOpen
+				r.hasNext = true
+				break
+			}
+			for _, name := range names {
+				tags[name] = true
+			}
+		} else if trimmed == "" {
+			tagsEnded = len(tags) > 0 && !inMovetext
+		} else if !strings.HasPrefix(line, "%") {
+			inMovetext = true
+			inComment = commentOpen
+		}
+
+		// An overlong line was cut short, so its full size is what counts
+		if sb.Len()+size > r.MaxGameSize {
+			tooLarge = true // Keep reading to find the end of the game
+			sb.Reset()
+		}
+		if !tooLarge {
+			sb.WriteString(line)
+		}
+	}
+
+	switch {
+	case firstLine == 0:
+		return "", 0, io.EOF
+	case tooLarge:
+		return "", 0, &PGNError{firstLine, 1, fmt.Sprintf("game is larger than %d bytes", r.MaxGameSize)}
+	}
+	return sb.String(), firstLine, nil
+}
+
+// pgnTagNames returns the names of the tags on a tag line
+func pgnTagNames(line string) []string {
+	var names []string
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			// Skip the tag value, which may contain brackets
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case '[':
+			start := i + 1
+			for start < len(line) && line[start] == ' ' {
+				start++
+			}
+			end := start
+			for end < len(line) && isSymbolContinue(line[end]) {
+				end++
+			}
+			names = append(names, line[start:end])
+			i = end - 1
+		}
+	}
+	return names
+}
+
+// pgnCommentState follows brace comments through a movetext line, which may
+// be scanned in several chunks
+type pgnCommentState struct {
+	open   bool // Inside a brace comment
+	quoted bool // Inside quoted text, which can't contain braces that matter
+	ended  bool // Past a semicolon, so the rest of the line is a comment
+}
+
+// scan moves the state past the next chunk of the line
+func (s *pgnCommentState) scan(chunk []byte) {
+	for _, c := range chunk {
+		switch {
+		case s.ended:
+			return
+		case s.open:
+			s.open = c != '}'
+		case s.quoted:
+			s.quoted = c != '"'
+		case c == '{':
+			s.open = true
+		case c == ';':
+			s.ended = true
+		case c == '"':
+			s.quoted = true
+		}
+	}
+}
+
+// ForEachPGNGame reads every game from r, calling fn for each one that parses.
+// Malformed games are passed to onError, if set, and skipped. Reading stops
+// early if fn returns an error, which is then returned.
+func ForEachPGNGame(r *PGNReader, fn func(*PGNGame) error, onError func(error)) error {
+	for {
+		pg, err := r.Next()
+		var pgnErr *PGNError
+		switch {
+		case err == io.EOF:
+			return nil
+		case errors.As(err, &pgnErr):
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		case err != nil:
+			return err
+		}
+		if err := fn(pg); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// repeatReader endlessly reads the same byte
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestPGNReaderSkipsOverlongLine(t *testing.T) {
+	const lineSize = 64 << 20
+	input := io.MultiReader(
+		strings.NewReader("[Event \"Long\"]\n\n1. e4 {"),
+		io.LimitReader(repeatReader('x'), lineSize),
+		strings.NewReader("} e5 *\n\n[Event \"Short\"]\n\n1. d4 d5 *\n"),
+	)
+	r := NewPGNReader(input)
+	r.MaxGameSize = 1024
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := r.Next()
+	runtime.ReadMemStats(&after)
+
+	var pgnErr *PGNError
+	if !errors.As(err, &pgnErr) {
+		t.Fatalf("first game error = %v, want a *PGNError", err)
+	}
+	if pgnErr.Line != 1 {
+		t.Errorf("error on line %d, want 1", pgnErr.Line)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > lineSize/8 {
+		t.Errorf("reading the game allocated %d bytes, want the %d-byte line dropped rather than buffered", allocated, lineSize)
+	}
+
+	pg, err := r.Next()
+	if err != nil {
+		t.Fatalf("second game: %v", err)
+	}
+	if pg.Tags["Event"] != "Short" || len(pg.Mainline()) != 2 {
+		t.Errorf("second game = %q with %d moves, want \"Short\" with 2", pg.Tags["Event"], len(pg.Mainline()))
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("after the last game error = %v, want io.EOF", err)
+	}
+}
+
+func TestPGNReaderGameWithoutMovetext(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"blank line after the tags", "[Event \"A\"]\n[FEN \"4k3/8/8/8/8/8/8/4K3 w - - 0 1\"]\n\n[Event \"B\"]\n\n1. e4 e5 *\n"},
+		{"repeated tag name", "[Event \"A\"]\n[FEN \"4k3/8/8/8/8/8/8/4K3 w - - 0 1\"]\n[Event \"B\"]\n\n1. e4 e5 *\n"},
+		{"tags on one line", "[Event \"A\"][FEN \"4k3/8/8/8/8/8/8/4K3 w - - 0 1\"]\n[Event \"B\"] 1. e4 e5 *\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewPGNReader(strings.NewReader(tt.input))
+
+			first, err := r.Next()
+			if err != nil {
+				t.Fatalf("first game: %v", err)
+			}
+			if first.Tags["Event"] != "A" || len(first.Mainline()) != 0 {
+				t.Errorf("first game = %q with %d moves, want \"A\" with none", first.Tags["Event"], len(first.Mainline()))
+			}
+
+			second, err := r.Next()
+			if err != nil {
+				t.Fatalf("second game: %v", err)
+			}
+			if second.Tags["Event"] != "B" || second.Tags["FEN"] != "" || len(second.Mainline()) != 2 {
+				t.Errorf("second game = %q (FEN %q) with %d moves, want \"B\" from the start with 2", second.Tags["Event"], second.Tags["FEN"], len(second.Mainline()))
+			}
+
+			if _, err := r.Next(); err != io.EOF {
+				t.Errorf("after the last game error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package game
+
+// recordPosition appends the current position to the position history. Two
+// positions count as the same if they have the same hash: the same pieces on
+// the same squares, the same side to move and the same castling and en
+// passant captures possible.
+func (g *Game) recordPosition() {
+	g.PositionHistory = append(g.PositionHistory, g.Hash)
+}
+
+// RepetitionCount returns how many times the current position has occurred,
+// including the current occurrence
+func (g *Game) RepetitionCount() int {
+	count := 0
+	for _, hash := range g.PositionHistory {
+		if hash == g.Hash {
+			count++
+		}
+	}
+	return count
+}
+
+// CanClaimThreefoldRepetition checks if the current position has occurred at least three times
+func (g *Game) CanClaimThreefoldRepetition() bool {
+	return g.State == Playing && g.RepetitionCount() >= 3
+}
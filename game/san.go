@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SAN returns the move in Standard Algebraic Notation, such as "Nbd7", "exd5",
+// "O-O", "e8=Q" or "Qh5#". The move must be legal in the current position.
+func (g *Game) SAN(move Move) string {
+	var sb strings.Builder
+
+	switch {
+	case move.Is(FlagKingsideCastle):
+		sb.WriteString("O-O")
+	case move.Is(FlagQueensideCastle):
+		sb.WriteString("O-O-O")
+	case abs(move.Piece) == Pawn:
+		if move.IsCapture() {
+			sb.WriteByte(move.From.String()[0])
+			sb.WriteByte('x')
+		}
+		sb.WriteString(move.To.String())
+		if move.IsPromotion() {
+			sb.WriteByte('=')
+			sb.WriteByte(pieceLetter(move.Promotion))
+		}
+	default:
+		sb.WriteByte(pieceLetter(abs(move.Piece)))
+		sb.WriteString(g.disambiguation(move))
+		if move.IsCapture() {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(move.To.String())
+	}
+
+	// Check and checkmate suffix
+	g.playMove(move)
+	if color := boolToInt(g.Turn, White, Black); IsKingInCheck(g.Board, color) {
+		if hasLegalMoves(g.Board, color, g) {
+			sb.WriteByte('+')
+		} else {
+			sb.WriteByte('#')
+		}
+	}
+	g.takeBack()
+
+	return sb.String()
+}
+
+// disambiguation returns the file, rank or square needed to tell a piece move
+// apart from moves of other pieces of the same type to the same square
+func (g *Game) disambiguation(move Move) string {
+	sameFile, sameRank, ambiguous := false, false, false
+	for _, other := range g.LegalMoves() {
+		if other.Piece != move.Piece || other.To != move.To || other.From == move.From {
+			continue
+		}
+		ambiguous = true
+		if other.From.X == move.From.X {
+			sameFile = true
+		}
+		if other.From.Y == move.From.Y {
+			sameRank = true
+		}
+	}
+
+	square := move.From.String()
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	}
+	return square
+}
+
+// ParseSAN finds the legal move described by a move in Standard Algebraic
+// Notation. Check, checkmate and annotation suffixes such as "+", "#", "!" and
+// "?" are ignored, and "0-0" is accepted for castling.
+func (g *Game) ParseSAN(san string) (Move, error) {
+	s := strings.TrimRight(san, "+#!?")
+	if s == "" {
+		return Move{}, fmt.Errorf("invalid SAN %q: empty move", san)
+	}
+
+	// Castling
+	switch s {
+	case "O-O", "0-0":
+		return g.findSANMove(san, func(m Move) bool { return m.Is(FlagKingsideCastle) })
+	case "O-O-O", "0-0-0":
+		return g.findSANMove(san, func(m Move) bool { return m.Is(FlagQueensideCastle) })
+	}
+
+	// Piece letter, pawn moves have none
+	piece := Pawn
+	if s[0] >= 'A' && s[0] <= 'Z' {
+		p, ok := pieceFromLetter(s[0])
+		if !ok || p == Pawn {
+			return Move{}, fmt.Errorf("invalid SAN %q: unknown piece %q", san, s[0])
+		}
+		piece = p
+		s = s[1:]
+	}
+
+	// Promotion suffix, with or without "="
+	promotion := Empty
+	if n := len(s); n > 0 && s[n-1] >= 'A' && s[n-1] <= 'Z' {
+		p, ok := pieceFromLetter(s[n-1])
+		if !ok || p == Pawn || p == King {
+			return Move{}, fmt.Errorf("invalid SAN %q: invalid promotion piece %q", san, s[n-1])
+		}
+		promotion = p
+		s = strings.TrimSuffix(s[:n-1], "=")
+	}
+
+	// Destination square
+	if len(s) < 2 {
+		return Move{}, fmt.Errorf("invalid SAN %q: missing destination square", san)
+	}
+	to, err := ParseSquare(s[len(s)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid SAN %q: %v", san, err)
+	}
+	s = s[:len(s)-2]
+
+	// Capture marker and disambiguation
+	capture := strings.HasSuffix(s, "x")
+	s = strings.TrimSuffix(s, "x")
+	fromX, fromY := -1, -1
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 'a' && c <= 'h' && fromX < 0:
+			fromX = int(c - 'a')
+		case c >= '1' && c <= '8' && fromY < 0:
+			fromY = int('8' - c)
+		default:
+			return Move{}, fmt.Errorf("invalid SAN %q: unexpected %q", san, c)
+		}
+	}
+	if piece == Pawn && capture && fromX < 0 {
+		return Move{}, fmt.Errorf("invalid SAN %q: pawn capture needs a file", san)
+	}
+
+	return g.findSANMove(san, func(m Move) bool {
+		return abs(m.Piece) == piece && m.To == to && !m.IsCastle() &&
+			(m.IsCapture() || !capture) && m.Promotion == promotion &&
+			(fromX < 0 || m.From.X == fromX) && (fromY < 0 || m.From.Y == fromY)
+	})
+}
+
+// findSANMove returns the only legal move matching a SAN description
+func (g *Game) findSANMove(san string, matches func(Move) bool) (Move, error) {
+	var found []Move
+	for _, move := range g.LegalMoves() {
+		if matches(move) {
+			found = append(found, move)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Move{}, fmt.Errorf("illegal move %q in position %s", san, g.FEN())
+	case 1:
+		return found[0], nil
+	}
+	return Move{}, fmt.Errorf("ambiguous move %q in position %s", san, g.FEN())
+}
@@ -0,0 +1,158 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SessionVersion is the version of the session format written by SaveSession
+const SessionVersion = 1
+
+// Session is the saved form of a game. Moves are stored in UCI notation and
+// replayed on load, so the undo and redo history survives; the current
+// position is stored as well to check the replay. Settings holds whatever the
+// application wants to restore along with the game.
+type Session struct {
+	Version    int               `json:"version"`
+	SavedAt    time.Time         `json:"savedAt"`
+	White      string            `json:"white,omitempty"`
+	Black      string            `json:"black,omitempty"`
+	InitialFEN string            `json:"initialFen,omitempty"`
+	Moves      []string          `json:"moves"`
+	Redo       []string          `json:"redo,omitempty"` // Moves taken back, next to redo last
+	FEN        string            `json:"fen"`
+	Result     string            `json:"result"`
+	Reason     string            `json:"reason,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Settings   map[string]string `json:"settings,omitempty"`
+}
+
+// endReasons lists every reason a game can end, for looking them up by name
+var endReasons = []EndReason{
+	Checkmate, Stalemate, FiftyMoveRule, SeventyFiveMoveRule, ThreefoldRepetition,
+	FivefoldRepetition, InsufficientMaterial, Timeout, Resignation,
+}
+
+// parseEndReason looks up a reason by the name returned from its String method
+func parseEndReason(name string) (EndReason, error) {
+	if name == "" {
+		return NoReason, nil
+	}
+	for _, reason := range endReasons {
+		if reason.String() == name {
+			return reason, nil
+		}
+	}
+	return NoReason, fmt.Errorf("unknown end reason %q", name)
+}
+
+// parseResult converts a PGN result token back into a game state
+func parseResult(result string) (GameState, error) {
+	for _, state := range []GameState{Playing, WhiteWins, BlackWins, Draw} {
+		if state.Result() == result {
+			return state, nil
+		}
+	}
+	return Playing, fmt.Errorf("unknown result %q", result)
+}
+
+// Session captures the game and the given application settings
+func (g *Game) Session(settings map[string]string) *Session {
+	s := &Session{
+		Version:    SessionVersion,
+		SavedAt:    time.Now().UTC(),
+		White:      g.Tags["White"],
+		Black:      g.Tags["Black"],
+		InitialFEN: g.InitialFEN,
+		Moves:      make([]string, 0, len(g.undoStack)),
+		FEN:        g.FEN(),
+		Result:     g.State.Result(),
+		Reason:     g.Reason.String(),
+		Tags:       g.Tags,
+		Settings:   settings,
+	}
+	for _, move := range g.Moves() {
+		s.Moves = append(s.Moves, move.UCI())
+	}
+	for _, move := range g.redoStack {
+		s.Redo = append(s.Redo, move.UCI())
+	}
+	return s
+}
+
+// SaveSession encodes the game and the given application settings as JSON
+func (g *Game) SaveSession(settings map[string]string) ([]byte, error) {
+	return json.MarshalIndent(g.Session(settings), "", "  ")
+}
+
+// Game rebuilds the saved game by replaying its moves
+func (s *Session) Game() (*Game, error) {
+	if s.Version < 1 || s.Version > SessionVersion {
+		return nil, fmt.Errorf("unsupported session version %d", s.Version)
+	}
+
+	g := NewGame()
+	if s.InitialFEN != "" {
+		var err error
+		if g, err = ParseFEN(s.InitialFEN); err != nil {
+			return nil, fmt.Errorf("invalid session: initial position: %v", err)
+		}
+	}
+	if err := g.ApplyUCIMoves(s.Moves); err != nil {
+		return nil, fmt.Errorf("invalid session: %v", err)
+	}
+
+	// Play the taken back moves and take them back again to rebuild the redo stack
+	redo := make([]string, len(s.Redo))
+	for i, move := range s.Redo {
+		redo[len(s.Redo)-1-i] = move
+	}
+	if err := g.ApplyUCIMoves(redo); err != nil {
+		return nil, fmt.Errorf("invalid session: redo %v", err)
+	}
+	for range redo {
+		g.Undo()
+	}
+
+	if fen := g.FEN(); fen != s.FEN {
+		return nil, fmt.Errorf("invalid session: moves lead to %q, expected %q", fen, s.FEN)
+	}
+
+	// Results that aren't visible on the board, such as resignations
+	state, err := parseResult(s.Result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session: %v", err)
+	}
+	reason, err := parseEndReason(s.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session: %v", err)
+	}
+	g.State, g.Reason = state, reason
+
+	g.Tags = make(map[string]string, len(s.Tags)+2)
+	for name, value := range s.Tags {
+		g.Tags[name] = value
+	}
+	if s.White != "" {
+		g.Tags["White"] = s.White
+	}
+	if s.Black != "" {
+		g.Tags["Black"] = s.Black
+	}
+	return g, nil
+}
+
+// LoadSession decodes a session written by SaveSession and rebuilds the game.
+// It also returns the application settings saved with it.
+func LoadSession(data []byte) (*Game, map[string]string, error) {
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, nil, fmt.Errorf("invalid session: %v", err)
+	}
+	g, err := s.Game()
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, s.Settings, nil
+}
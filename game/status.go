@@ -0,0 +1,86 @@
+package game
+
+// Status describes whether a game is over, who won and why
+type Status struct {
+	State  GameState
+	Reason EndReason
+}
+
+// Status evaluates the current position and returns the outcome of the game.
+// Checkmate and stalemate are judged with the full legal move generator, so
+// castling and en passant escapes count. Results that are not visible on the
+// board, such as resignations, timeouts and claimed draws, are kept once set.
+func (g *Game) Status() Status {
+	if g.State != Playing {
+		return Status{g.State, g.Reason}
+	}
+
+	color := boolToInt(g.Turn, White, Black)
+	if !hasLegalMoves(g.Board, color, g) {
+		if !IsKingInCheck(g.Board, color) {
+			return Status{Draw, Stalemate}
+		}
+		if color == White {
+			return Status{BlackWins, Checkmate}
+		}
+		return Status{WhiteWins, Checkmate}
+	}
+
+	switch {
+	case IsInsufficientMaterial(g.Board):
+		return Status{Draw, InsufficientMaterial}
+	case g.HalfmoveClock >= 150: // Seventy-five moves by each side
+		return Status{Draw, SeventyFiveMoveRule}
+	case g.RepetitionCount() >= 5:
+		return Status{Draw, FivefoldRepetition}
+	}
+	return Status{Playing, NoReason}
+}
+
+// IsOver checks if the game has ended
+func (s Status) IsOver() bool {
+	return s.State != Playing
+}
+
+// UpdateGameState evaluates the position with Status and records the outcome
+func (g *Game) UpdateGameState() {
+	status := g.Status()
+	g.State = status.State
+	g.Reason = status.Reason
+}
+
+// CanClaimFiftyMoveDraw checks if fifty moves by each side have been played
+// without a pawn move or capture
+func (g *Game) CanClaimFiftyMoveDraw() bool {
+	return g.State == Playing && g.HalfmoveClock >= 100
+}
+
+// ClaimDraw ends the game as a draw if the side to move is entitled to claim one
+// by threefold repetition or the fifty-move rule. It returns false and leaves
+// the game untouched otherwise.
+func (g *Game) ClaimDraw() bool {
+	switch {
+	case g.CanClaimThreefoldRepetition():
+		g.Reason = ThreefoldRepetition
+	case g.CanClaimFiftyMoveDraw():
+		g.Reason = FiftyMoveRule
+	default:
+		return false
+	}
+	g.State = Draw
+	return true
+}
+
+// Resign ends the game with a win for the opponent of the specified color
+func (g *Game) Resign(color int) {
+	if g.State != Playing {
+		return
+	}
+
+	g.Reason = Resignation
+	if color == White {
+		g.State = BlackWins
+	} else {
+		g.State = WhiteWins
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextOptions controls how a board is drawn as text
+type TextOptions struct {
+	Unicode   bool       // Draw pieces as chess figurines instead of FEN letters
+	Flipped   bool       // Put Black at the bottom of the board
+	Highlight []Position // Squares to mark with brackets
+}
+
+// figurines maps signed pieces to their Unicode chess symbols
+var figurines = map[int]string{
+	King: "♔", Queen: "♕", Rook: "♖", Bishop: "♗", Knight: "♘", Pawn: "♙",
+	-King: "♚", -Queen: "♛", -Rook: "♜", -Bishop: "♝", -Knight: "♞", -Pawn: "♟",
+}
+
+// String draws the board with FEN letters, White at the bottom
+func (g *Game) String() string {
+	return g.Text(TextOptions{})
+}
+
+// Text draws the board with rank and file labels, followed by the side to
+// move, castling rights, en passant square and, once the game is over, its
+// result. It is meant for logs, test failures and terminals.
+func (g *Game) Text(opts TextOptions) string {
+	highlighted := make(map[Position]bool, len(opts.Highlight))
+	for _, pos := range opts.Highlight {
+		highlighted[pos] = true
+	}
+
+	// Rows and columns in drawing order
+	order := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	if opts.Flipped {
+		order = []int{7, 6, 5, 4, 3, 2, 1, 0}
+	}
+
+	var sb strings.Builder
+	border := "  +" + strings.Repeat("-", 24) + "+\n"
+	sb.WriteString(border)
+	for _, y := range order {
+		fmt.Fprintf(&sb, "%c |", '8'-y)
+		for _, x := range order {
+			pos := Position{x, y}
+			symbol := g.squareSymbol(pos, opts.Unicode)
+			if highlighted[pos] {
+				fmt.Fprintf(&sb, "[%s]", symbol)
+			} else {
+				fmt.Fprintf(&sb, " %s ", symbol)
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	sb.WriteString(border)
+
+	// File labels
+	labels := "   "
+	for _, x := range order {
+		labels += fmt.Sprintf(" %c ", 'a'+x)
+	}
+	sb.WriteString(strings.TrimRight(labels, " ") + "\n")
+
+	// Game state
+	turn := "White"
+	if !g.Turn {
+		turn = "Black"
+	}
+	enPassant := "-"
+	if g.EnPassantTarget != nil {
+		enPassant = g.EnPassantTarget.String()
+	}
+	fmt.Fprintf(&sb, "%s to move, castling %v, en passant %s\n", turn, g.Castling, enPassant)
+	if g.State != Playing {
+		fmt.Fprintf(&sb, "Result %s (%v)\n", g.State.Result(), g.Reason)
+	}
+	return sb.String()
+}
+
+// squareSymbol returns the character drawn for a square
+func (g *Game) squareSymbol(pos Position, unicode bool) string {
+	piece := g.Board[pos.Y][pos.X]
+	switch {
+	case piece == Empty && unicode:
+		return "·"
+	case piece == Empty:
+		return "."
+	case unicode:
+		return figurines[piece]
+	}
+	return string(pieceLetter(piece))
+}
@@ -1,23 +1,5 @@
 package game
 
-import (
-	"fmt"
-	"image"
-	"path/filepath"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/srwiley/oksvg"
-	"github.com/srwiley/rasterx"
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/gofont/goregular"
-	"golang.org/x/image/font/opentype"
-)
-
-const (
-	BoardSize = 480 // Makes each square 60x60
-	PieceSize = 60  // Size of piece images
-)
-
 // GameState represents the current state of the game
 type GameState int
 
@@ -25,8 +7,63 @@ const (
 	Playing GameState = iota
 	WhiteWins
 	BlackWins
+	Draw
+)
+
+// Result returns the PGN result token for the state
+func (s GameState) Result() string {
+	switch s {
+	case WhiteWins:
+		return "1-0"
+	case BlackWins:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return "*"
+}
+
+// EndReason explains why a game is over
+type EndReason int
+
+const (
+	NoReason EndReason = iota // Game is still being played
+	Checkmate
+	Stalemate
+	FiftyMoveRule       // Claimed after fifty moves without a pawn move or capture
+	SeventyFiveMoveRule // Automatic after seventy-five such moves
+	ThreefoldRepetition // Claimed when a position occurs for the third time
+	FivefoldRepetition  // Automatic when a position occurs for the fifth time
+	InsufficientMaterial
+	Timeout
+	Resignation
 )
 
+// String returns a human-readable name for the reason
+func (r EndReason) String() string {
+	switch r {
+	case Checkmate:
+		return "Checkmate"
+	case Stalemate:
+		return "Stalemate"
+	case FiftyMoveRule:
+		return "Fifty-move rule"
+	case SeventyFiveMoveRule:
+		return "Seventy-five-move rule"
+	case ThreefoldRepetition:
+		return "Threefold repetition"
+	case FivefoldRepetition:
+		return "Fivefold repetition"
+	case InsufficientMaterial:
+		return "Insufficient material"
+	case Timeout:
+		return "Time out"
+	case Resignation:
+		return "Resignation"
+	}
+	return ""
+}
+
 // Game represents the main game state
 type Game struct {
 	Board         [8][8]int // 0 = empty, positive = white pieces, negative = black pieces
@@ -35,110 +72,56 @@ type Game struct {
 		Selected bool
 	}
 	Turn          bool // true = white, false = black
-	ValidMoves    []Position
+	ValidMoves    []Move
 	State         GameState
-	AnimationTick int // Used for victory animation
+	Reason        EndReason // Why the game ended, NoReason while playing
+	AnimationTick int       // Used for victory animation
 
 	// Castling state
-	HasMoved map[Position]bool // Tracks if pieces have moved (for castling)
+	Castling CastlingRights // Castling moves still available to each side
 
 	// En passant state
-	LastMove struct {
-		From, To Position
-		Piece    int
-	}
+	LastMove        Move      // Most recently played move
 	EnPassantTarget *Position // Square where en passant capture is possible
-}
-
-var (
-	defaultFont font.Face
-	PieceImages map[int]*ebiten.Image // Maps piece type to its image
-)
-
-// loadPieceImage loads an SVG piece image and converts it to an Ebiten image
-func loadPieceImage(path string) (*ebiten.Image, error) {
-	// Read and parse SVG
-	icon, err := oksvg.ReadIcon(path, oksvg.StrictErrorMode)
-	if err != nil {
-		return nil, fmt.Errorf("error reading SVG: %v", err)
-	}
-
-	// Set size
-	icon.SetTarget(0, 0, float64(PieceSize), float64(PieceSize))
-
-	// Create RGBA image
-	rgba := image.NewRGBA(image.Rect(0, 0, PieceSize, PieceSize))
-	scanner := rasterx.NewScannerGV(PieceSize, PieceSize, rgba, rgba.Bounds())
-	raster := rasterx.NewDasher(PieceSize, PieceSize, scanner)
 
-	// Render SVG
-	icon.Draw(raster, 1.0)
+	// Move counters
+	HalfmoveClock  int // Halfmoves since the last pawn move or capture
+	FullmoveNumber int // Starts at 1 and increments after Black's move
 
-	// Convert to Ebiten image
-	return ebiten.NewImageFromImage(rgba), nil
-}
-
-// InitPieces loads all piece images
-func InitPieces() error {
-	PieceImages = make(map[int]*ebiten.Image)
-	pieces := map[int]string{
-		Pawn:   "p",
-		Knight: "n",
-		Bishop: "b",
-		Rook:   "r",
-		Queen:  "q",
-		King:   "k",
-	}
-
-	for pieceType, letter := range pieces {
-		// Load white piece
-		whitePath := filepath.Join("assets", "pieces", fmt.Sprintf("w%s.svg", letter))
-		whiteImg, err := loadPieceImage(whitePath)
-		if err != nil {
-			return fmt.Errorf("error loading white piece %s: %v", letter, err)
-		}
-		PieceImages[pieceType] = whiteImg
-
-		// Load black piece
-		blackPath := filepath.Join("assets", "pieces", fmt.Sprintf("b%s.svg", letter))
-		blackImg, err := loadPieceImage(blackPath)
-		if err != nil {
-			return fmt.Errorf("error loading black piece %s: %v", letter, err)
-		}
-		PieceImages[-pieceType] = blackImg
-	}
+	// Repetition state
+	Hash            uint64   // Zobrist hash of the current position
+	PositionHistory []uint64 // Hash of every position reached so far, including the current one
 
-	return nil
-}
+	// Game record
+	InitialFEN string            // Position the game started from, empty for the standard start
+	Tags       map[string]string // PGN tag pairs such as Event, White and Black
 
-// InitFonts initializes the fonts used in the game
-func InitFonts() error {
-	tt, err := opentype.Parse(goregular.TTF)
-	if err != nil {
-		return err
-	}
+	// Undo and redo state
+	undoStack []moveRecord // Moves played, with the state needed to take them back
+	redoStack []Move       // Moves taken back, most recent last
 
-	const dpi = 72
-	defaultFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    36,
-		DPI:     dpi,
-		Hinting: font.HintingFull,
-	})
-	if err != nil {
-		return err
+	// Promotion state
+	PendingPromotion struct {
+		From, To Position
+		Active   bool // Waiting for the player to pick a promotion piece
 	}
 
-	return nil
+	// View state
+	Flipped     bool // Draw the board from Black's side, with rank 1 at the top
+	ShowThreats bool // Mark the side to move's pieces that are under attack
 }
 
 // NewGame creates and initializes a new game
 func NewGame() *Game {
 	g := &Game{
-		Turn:     true, // White starts
-		State:    Playing,
-		HasMoved: make(map[Position]bool),
+		Turn:           true, // White starts
+		State:          Playing,
+		Castling:       AllCastling,
+		FullmoveNumber: 1,
 	}
 	g.initializeBoard()
+	g.Hash = g.ComputeHash()
+	g.recordPosition()
 	return g
 }
 
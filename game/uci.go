@@ -0,0 +1,85 @@
+package game
+
+import "fmt"
+
+// FormatUCIMove encodes a move between two squares in the UCI long algebraic
+// format, such as "e2e4", or "e7e8q" for a promotion. Castling is written as
+// the king's move, "e1g1". Pass Empty as promotion for other moves.
+func FormatUCIMove(from, to Position, promotion int) string {
+	s := from.String() + to.String()
+	if promotion != Empty {
+		s += string(pieceLetters[promotion])
+	}
+	return s
+}
+
+// ParseUCIMove decodes a move in the UCI long algebraic format into its
+// squares and promotion piece type (Empty if there is none)
+func ParseUCIMove(s string) (from, to Position, promotion int, err error) {
+	if len(s) != 4 && len(s) != 5 {
+		return from, to, Empty, fmt.Errorf("invalid UCI move %q: expected 4 or 5 characters", s)
+	}
+	if from, err = ParseSquare(s[0:2]); err != nil {
+		return from, to, Empty, fmt.Errorf("invalid UCI move %q: %v", s, err)
+	}
+	if to, err = ParseSquare(s[2:4]); err != nil {
+		return from, to, Empty, fmt.Errorf("invalid UCI move %q: %v", s, err)
+	}
+	if len(s) == 5 {
+		piece, ok := pieceFromLetter(s[4])
+		if !ok || piece > 0 || !isPromotionPiece(-piece) { // UCI uses lowercase letters
+			return from, to, Empty, fmt.Errorf("invalid UCI move %q: invalid promotion piece %q", s, s[4])
+		}
+		promotion = -piece
+	}
+	return from, to, promotion, nil
+}
+
+// isPromotionPiece checks if a piece type is a valid promotion choice
+func isPromotionPiece(piece int) bool {
+	for _, p := range PromotionPieces {
+		if p == piece {
+			return true
+		}
+	}
+	return false
+}
+
+// UCI returns the move in the UCI long algebraic format
+func (m Move) UCI() string {
+	return FormatUCIMove(m.From, m.To, m.Promotion)
+}
+
+// ParseUCI finds the legal move described by a UCI long algebraic string
+func (g *Game) ParseUCI(s string) (Move, error) {
+	from, to, promotion, err := ParseUCIMove(s)
+	if err != nil {
+		return Move{}, err
+	}
+
+	move, ok := g.FindMove(from, to, promotion)
+	if !ok || move.Promotion != promotion {
+		return Move{}, fmt.Errorf("illegal move %q in position %s", s, g.FEN())
+	}
+	return move, nil
+}
+
+// ApplyUCIMoves plays a sequence of UCI moves. If any move is malformed or
+// illegal, the game is left unchanged and the error names the offending move.
+func (g *Game) ApplyUCIMoves(moves []string) error {
+	redo := g.redoStack
+	for i, s := range moves {
+		move, err := g.ParseUCI(s)
+		if err != nil {
+			// Roll back the moves already played
+			for j := 0; j < i; j++ {
+				g.takeBack()
+			}
+			g.redoStack = redo
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+		g.MakeMove(move)
+		g.UpdateGameState()
+	}
+	return nil
+}
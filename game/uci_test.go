@@ -0,0 +1,35 @@
+package game
+
+import "testing"
+
+func TestApplyUCIMovesRejectsWrongSide(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+	}{
+		{"black moves first", []string{"e7e5"}},
+		{"white moves twice", []string{"e2e4", "d2d4"}},
+		{"black moves twice", []string{"e2e4", "e7e5", "d7d5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGame()
+			if err := g.ApplyUCIMoves(tt.moves); err == nil {
+				t.Fatalf("ApplyUCIMoves(%v) succeeded, leaving %s", tt.moves, g.FEN())
+			}
+			if g.FEN() != StartFEN {
+				t.Errorf("position after the error is %s, want the start", g.FEN())
+			}
+		})
+	}
+}
+
+func TestFindMoveWrongSide(t *testing.T) {
+	g := mustParseFEN(t, "4k3/8/8/8/8/8/4p3/4K3 b - - 0 1")
+	if move, ok := g.FindMove(mustParseSquare(t, "e1"), mustParseSquare(t, "e2"), Empty); ok {
+		t.Errorf("found %s for White with Black to move", move.UCI())
+	}
+	if _, ok := g.FindMove(mustParseSquare(t, "e8"), mustParseSquare(t, "d8"), Empty); !ok {
+		t.Error("did not find e8d8 for Black")
+	}
+}
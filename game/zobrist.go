@@ -0,0 +1,89 @@
+package game
+
+// Zobrist keys. A position's hash is the XOR of the keys for each piece on
+// its square, the castling rights, the en passant file and the side to move,
+// so a move only has to XOR out what it removes and XOR in what it adds.
+var (
+	zobristPieces      [2][7][64]uint64 // Indexed by colorIndex, piece type and square index
+	zobristCastling    [16]uint64       // Indexed by the CastlingRights bits
+	zobristEnPassant   [8]uint64        // Indexed by file
+	zobristBlackToMove uint64
+)
+
+func init() {
+	rng := xorshift(0x5A17C0DE) // Fixed seed, so hashes are the same on every run
+	for ci := range zobristPieces {
+		for piece := Pawn; piece <= King; piece++ {
+			for sq := range zobristPieces[ci][piece] {
+				zobristPieces[ci][piece][sq] = rng.next()
+			}
+		}
+	}
+	for i := range zobristCastling {
+		zobristCastling[i] = rng.next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = rng.next()
+	}
+	zobristBlackToMove = rng.next()
+}
+
+// pieceHash returns the key for a piece on a square, or 0 for an empty square
+func pieceHash(piece int, pos Position) uint64 {
+	if piece == Empty {
+		return 0
+	}
+	return zobristPieces[colorIndex(sign(piece))][abs(piece)][squareIndex(pos)]
+}
+
+// ComputeHash calculates the Zobrist hash of the current position from
+// scratch. Hash holds the same value, kept up to date move by move.
+func (g *Game) ComputeHash() uint64 {
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			pos := Position{X: x, Y: y}
+			hash ^= pieceHash(g.Board[y][x], pos)
+		}
+	}
+	return hash ^ g.stateHash()
+}
+
+// stateHash returns the part of the hash that doesn't depend on where the
+// pieces stand: castling rights, en passant file and side to move
+func (g *Game) stateHash() uint64 {
+	hash := zobristCastling[g.Castling&AllCastling]
+	if target := g.capturableEnPassant(); target != nil {
+		hash ^= zobristEnPassant[target.X]
+	}
+	if !g.Turn {
+		hash ^= zobristBlackToMove
+	}
+	return hash
+}
+
+// capturableEnPassant returns the en passant target if a pawn of the side to
+// move stands ready to capture on it. Otherwise the target makes no
+// difference to the position, and nil is returned.
+func (g *Game) capturableEnPassant() *Position {
+	if g.EnPassantTarget == nil {
+		return nil
+	}
+
+	color := boolToInt(g.Turn, White, Black)
+	pawnY := g.EnPassantTarget.Y + color // The capturing pawn stands one rank behind the target
+	for _, dx := range []int{-1, 1} {
+		pawn := Position{g.EnPassantTarget.X + dx, pawnY}
+		if IsValidPosition(pawn) && g.Board[pawn.Y][pawn.X] == color*Pawn {
+			return g.EnPassantTarget
+		}
+	}
+	return nil
+}
+
+// setPiece puts a piece on a square, or clears it with Empty, and updates the
+// hash to match
+func (g *Game) setPiece(pos Position, piece int) {
+	g.Hash ^= pieceHash(g.Board[pos.Y][pos.X], pos) ^ pieceHash(piece, pos)
+	g.Board[pos.Y][pos.X] = piece
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
 	"chessgame/game"
+	"chessgame/render"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -12,29 +19,170 @@ import (
 const (
 	screenWidth  = 800
 	screenHeight = 600
+	sessionFile  = "chess-session.json" // Where Ctrl+S and Ctrl+O save and load the session
+	searchDepth  = 3                    // Plies the computer looks ahead
 )
 
 type Game struct {
-	board *game.Game
+	board    *game.Game
+	settings map[string]string // App settings saved along with the game
+	computer int               // Color the computer plays, 0 when nobody does
+
+	search       <-chan game.SearchResult // Background search of searchKey's position, while it runs
+	searchKey    positionKey
+	searchResult *game.SearchResult // Result for searchKey's position, once the search is done
+
+	autosaver      *autosaver        // Nil if the state directory is unavailable
+	resume         *game.Game        // Unfinished autosaved game offered on launch
+	resumeSettings map[string]string // Settings saved with the resumable game
+}
+
+// positionKey identifies a position in the game being played. Undoing and
+// redoing a move comes back to the same key.
+type positionKey struct {
+	hash uint64
+	ply  int
 }
 
-func NewGame() *Game {
-	return &Game{
-		board: game.NewGame(),
+// NewGame starts a game from board, or from the standard position if board is
+// nil, in the mode and orientation given by the options
+func NewGame(board *game.Game, opts *options) *Game {
+	if board == nil {
+		board = game.NewGame()
 	}
+	if board.Tags == nil {
+		board.Tags = map[string]string{
+			"Event": "Casual game",
+			"Site":  "Chess Game",
+			"Date":  time.Now().Format("2006.01.02"),
+		}
+	}
+	g := &Game{
+		board: board,
+		settings: map[string]string{
+			"mode": opts.mode,
+			"side": opts.side,
+		},
+	}
+	g.applySettings()
+	return g
 }
 
 func (g *Game) Update() error {
+	// Ask whether to resume the last unfinished game before anything else
+	if g.resume != nil {
+		if inpututil.IsKeyJustPressed(ebiten.KeyY) {
+			g.board = g.resume
+			g.settings = g.resumeSettings
+			if g.settings == nil {
+				g.settings = make(map[string]string)
+			}
+			g.resume = nil
+			g.applySettings()
+			g.autosaveRestart()
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+			g.resume = nil
+			g.autosaveRestart()
+		}
+		return nil
+	}
+
+	// Undo and redo work even after the game has ended
+	if ctrl := ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta); ctrl {
+		if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+			g.undo()
+			return nil
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyY) {
+			g.redo()
+			return nil
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			if err := g.saveSession(sessionFile); err != nil {
+				log.Printf("Error saving session: %v", err)
+			} else {
+				log.Printf("Session saved to %s", sessionFile)
+			}
+			return nil
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+			if err := g.loadSession(sessionFile); err != nil {
+				log.Printf("Error loading session: %v", err)
+			} else {
+				log.Printf("Session loaded from %s", sessionFile)
+				g.applySettings()
+				g.autosaveRestart()
+			}
+			return nil
+		}
+	}
+
+	// Export the game as PGN, finished or not
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		if path, err := g.exportPGN(); err != nil {
+			log.Printf("Error exporting PGN: %v", err)
+		} else {
+			log.Printf("Game saved to %s", path)
+		}
+		return nil
+	}
+
+	// Toggle marking the pieces under attack
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		g.board.ShowThreats = !g.board.ShowThreats
+		g.settings["threats"] = strconv.FormatBool(g.board.ShowThreats)
+		return nil
+	}
+
 	// Update animation tick if game is over
 	if g.board.State != game.Playing {
 		g.board.AnimationTick++
 		return nil
 	}
 
+	// The computer thinks in the background, so the window stays responsive,
+	// and replies once its search is done
+	if g.computerToMove() {
+		if result, ok := g.searchPosition(); ok && result.OK {
+			g.makeMove(result.Move)
+		}
+		return nil
+	}
+
+	// In analysis mode the engine keeps looking for the best move in the
+	// current position, while both sides move freely
+	if g.settings["mode"] == modeAnalysis {
+		g.searchPosition()
+	}
+
+	// Claim a draw by threefold repetition or the fifty-move rule
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		if g.board.ClaimDraw() {
+			g.autosave("")
+		}
+		return nil
+	}
+
+	// Wait for a promotion choice before handing the turn over
+	if g.board.PendingPromotion.Active {
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			x, y := ebiten.CursorPosition()
+			if piece, ok := render.GetPromotionChoice(g.board, x, y); ok {
+				if move, ok := g.board.FindMove(g.board.PendingPromotion.From, g.board.PendingPromotion.To, piece); ok {
+					g.makeMove(move)
+				}
+			}
+			// Clicking outside the picker cancels the promotion
+			g.board.PendingPromotion.Active = false
+		}
+		return nil
+	}
+
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
-		if game.IsInsideBoard(x, y) {
-			boardX, boardY := game.GetBoardCoordinates(x, y)
+		if render.IsInsideBoard(x, y) {
+			square := render.GetBoardSquare(g.board, x, y)
+			boardX, boardY := square.X, square.Y
 			if !g.board.SelectedPiece.Selected {
 				// Try to select a piece
 				piece := g.board.Board[boardY][boardX]
@@ -47,24 +195,22 @@ func (g *Game) Update() error {
 			} else {
 				// Try to move the selected piece
 				targetPos := game.Position{X: boardX, Y: boardY}
-				validMove := false
-				for _, move := range g.board.ValidMoves {
-					if move.X == targetPos.X && move.Y == targetPos.Y {
-						validMove = true
+				var validMove *game.Move
+				for i, move := range g.board.ValidMoves {
+					if move.To == targetPos {
+						validMove = &g.board.ValidMoves[i]
 						break
 					}
 				}
 
-				if validMove {
-					// Make the move
-					from := game.Position{X: g.board.SelectedPiece.X, Y: g.board.SelectedPiece.Y}
-					g.board.MakeMove(from, targetPos)
-
-					// Check for checkmate
-					if game.IsCheckmate(g.board.Board, 1) {
-						g.board.State = game.BlackWins
-					} else if game.IsCheckmate(g.board.Board, -1) {
-						g.board.State = game.WhiteWins
+				if validMove != nil {
+					if validMove.IsPromotion() {
+						// Let the player pick the promotion piece first
+						g.board.PendingPromotion.From = validMove.From
+						g.board.PendingPromotion.To = validMove.To
+						g.board.PendingPromotion.Active = true
+					} else {
+						g.makeMove(*validMove)
 					}
 				}
 
@@ -78,8 +224,219 @@ func (g *Game) Update() error {
 	return nil
 }
 
+// makeMove plays a move on the board and checks whether it ended the game
+func (g *Game) makeMove(move game.Move) {
+	g.board.MakeMove(move)
+
+	// Check whether the move ended the game
+	g.board.UpdateGameState()
+
+	g.autosave("move " + move.UCI())
+}
+
+// undo takes back the last move. Against the computer it keeps going until it
+// is the player's turn again, so the computer doesn't just replay its move.
+func (g *Game) undo() {
+	for g.board.Undo() {
+		g.resetSelection()
+		g.autosave("undo")
+		if !g.computerToMove() {
+			return
+		}
+	}
+}
+
+// redo replays the last move taken back, along with the computer's reply
+func (g *Game) redo() {
+	for g.board.Redo() {
+		g.resetSelection()
+		g.autosave("redo")
+		if !g.computerToMove() {
+			return
+		}
+	}
+}
+
+// searchPosition returns the engine's result for the current position,
+// starting a background search if none is running for it. It returns false
+// until the search is done. Results for positions that have since been left,
+// by a move, undo or load, are dropped.
+func (g *Game) searchPosition() (game.SearchResult, bool) {
+	key := g.positionKey()
+	if key != g.searchKey || (g.search == nil && g.searchResult == nil) {
+		g.search = g.board.StartSearch(searchDepth)
+		g.searchKey = key
+		g.searchResult = nil
+	}
+	if g.searchResult != nil {
+		return *g.searchResult, true
+	}
+
+	select {
+	case result := <-g.search:
+		g.search = nil
+		g.searchResult = &result
+		return result, true
+	default:
+		return game.SearchResult{}, false
+	}
+}
+
+// positionKey returns the key of the current position
+func (g *Game) positionKey() positionKey {
+	return positionKey{hash: g.board.Hash, ply: len(g.board.PositionHistory)}
+}
+
+// analysis returns the engine's suggestion for the current position in
+// analysis mode, once its search is done
+func (g *Game) analysis() (game.SearchResult, bool) {
+	if g.settings["mode"] != modeAnalysis || g.board.State != game.Playing {
+		return game.SearchResult{}, false
+	}
+	if g.searchResult == nil || g.searchKey != g.positionKey() || !g.searchResult.OK {
+		return game.SearchResult{}, false
+	}
+	return *g.searchResult, true
+}
+
+// computerToMove checks whether the computer should play the next move
+func (g *Game) computerToMove() bool {
+	if g.computer == 0 || g.board.State != game.Playing {
+		return false
+	}
+	return (g.computer == game.White) == g.board.Turn
+}
+
+// applySettings sets up the board orientation and the computer opponent from
+// the settings, after they have been changed or loaded
+func (g *Game) applySettings() {
+	g.board.Flipped = g.settings["side"] == "black"
+	g.board.ShowThreats = g.settings["threats"] == "true"
+
+	g.computer = 0
+	if g.settings["mode"] == modeComputer {
+		if g.board.Flipped {
+			g.computer = game.White
+		} else {
+			g.computer = game.Black
+		}
+	}
+}
+
+// autosave appends an entry to the autosave journal and snapshots the game.
+// An empty entry only updates the snapshot. Errors are logged, not fatal.
+func (g *Game) autosave(entry string) {
+	if !g.autosaveEnabled() {
+		return
+	}
+
+	var err error
+	if entry == "" {
+		err = g.autosaver.snapshot(g.board, g.settings)
+	} else {
+		err = g.autosaver.record(entry, g.board, g.settings)
+	}
+	if err != nil {
+		log.Printf("Error autosaving: %v", err)
+	}
+}
+
+// autosaveRestart starts a fresh autosave journal for the current game
+func (g *Game) autosaveRestart() {
+	if !g.autosaveEnabled() {
+		return
+	}
+	if err := g.autosaver.start(g.board, g.settings); err != nil {
+		log.Printf("Error autosaving: %v", err)
+	}
+}
+
+// autosaveEnabled checks whether moves should be autosaved. Analysis games
+// are never saved, so exploring a line can't replace the real game.
+func (g *Game) autosaveEnabled() bool {
+	return g.autosaver != nil && g.settings["mode"] != modeAnalysis
+}
+
+// startAutosave opens the autosave directory. If offerResume is set, an
+// unfinished autosaved game is offered to the player; otherwise, or if there
+// is none, the new game starts a fresh journal.
+func (g *Game) startAutosave(offerResume bool) {
+	saver, err := newAutosaver()
+	if err != nil {
+		log.Printf("Autosave disabled: %v", err)
+		return
+	}
+	g.autosaver = saver
+
+	if !offerResume {
+		g.autosaveRestart()
+		return
+	}
+	if board, settings, err := saver.load(); err == nil && board.State == game.Playing && len(board.Moves()) > 0 {
+		g.resume = board
+		g.resumeSettings = settings
+		return
+	}
+	g.autosaveRestart()
+}
+
+// exportPGN writes the game to a timestamped PGN file in the working directory
+func (g *Game) exportPGN() (string, error) {
+	pgn, err := g.board.PGN()
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("chess-%s.pgn", time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(path, []byte(pgn), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// saveSession writes the game and settings to a JSON session file
+func (g *Game) saveSession(path string) error {
+	data, err := g.board.SaveSession(g.settings)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// loadSession replaces the game and settings with those in a JSON session file
+func (g *Game) loadSession(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	board, settings, err := game.LoadSession(data)
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		settings = make(map[string]string)
+	}
+	g.board = board
+	g.settings = settings
+	return nil
+}
+
+// resetSelection clears the selected piece and any pending promotion
+func (g *Game) resetSelection() {
+	g.board.SelectedPiece.Selected = false
+	g.board.ValidMoves = nil
+	g.board.PendingPromotion.Active = false
+}
+
 func (g *Game) Draw(screen *ebiten.Image) {
-	game.RenderBoard(screen, g.board)
+	if g.resume != nil {
+		render.RenderBoard(screen, g.resume)
+		render.RenderPrompt(screen, "Resume last game?", "Y = yes, N = no")
+		return
+	}
+	render.RenderBoard(screen, g.board)
+	if result, ok := g.analysis(); ok && !g.board.PendingPromotion.Active {
+		render.RenderAnalysis(screen, g.board, result)
+	}
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
@@ -87,19 +444,46 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 }
 
 func main() {
-	ebiten.SetWindowSize(screenWidth, screenHeight)
+	// The perft subcommand checks the move generator without opening a window
+	if len(os.Args) > 1 && os.Args[1] == "perft" {
+		os.Exit(runPerft(os.Args[2:]))
+	}
+
+	opts, err := parseOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	board, err := opts.startingBoard()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chessgame: %v\n", err)
+		os.Exit(1)
+	}
+
+	ebiten.SetWindowSize(opts.width, opts.height)
 	ebiten.SetWindowTitle("Chess Game")
 
-	if err := game.InitFonts(); err != nil {
+	if err := render.InitFonts(); err != nil {
 		log.Fatal(err)
 	}
 
-	if err := game.InitPieces(); err != nil {
+	if err := render.InitPieces(); err != nil {
 		log.Fatal(err)
 	}
 
-	game := NewGame()
-	if err := ebiten.RunGame(game); err != nil {
+	game := NewGame(board, opts)
+	// Analysis games aren't autosaved, and an explicit starting position wins over resuming
+	if opts.mode != modeAnalysis {
+		game.startAutosave(board == nil)
+	}
+	err = ebiten.RunGame(game)
+	if game.autosaver != nil {
+		game.autosaver.close()
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
 }
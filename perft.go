@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"chessgame/game"
+)
+
+// runPerft implements "chessgame perft <fen> <depth>". It prints the node
+// count below each root move and the total, and returns the exit status.
+func runPerft(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: chessgame perft <fen> <depth>")
+		return 2
+	}
+
+	board, err := game.ParseFEN(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chessgame: %v\n", err)
+		return 2
+	}
+	depth, err := strconv.Atoi(args[1])
+	if err != nil || depth < 1 {
+		fmt.Fprintf(os.Stderr, "chessgame: depth must be a positive number, got %q\n", args[1])
+		return 2
+	}
+
+	start := time.Now()
+	var total int64
+	for _, result := range board.Divide(depth) {
+		fmt.Printf("%s: %d\n", result.Move.UCI(), result.Nodes)
+		total += result.Nodes
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("\nNodes searched: %d\n", total)
+	fmt.Printf("Time: %v (%.0f nodes/s)\n", elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+	return 0
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"chessgame/game"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+var (
+	defaultFont font.Face
+	PieceImages map[int]*ebiten.Image // Maps piece type to its image
+)
+
+// loadPieceImage loads an SVG piece image and converts it to an Ebiten image
+func loadPieceImage(path string) (*ebiten.Image, error) {
+	// Read and parse SVG
+	icon, err := oksvg.ReadIcon(path, oksvg.StrictErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SVG: %v", err)
+	}
+
+	// Set size
+	icon.SetTarget(0, 0, float64(PieceSize), float64(PieceSize))
+
+	// Create RGBA image
+	rgba := image.NewRGBA(image.Rect(0, 0, PieceSize, PieceSize))
+	scanner := rasterx.NewScannerGV(PieceSize, PieceSize, rgba, rgba.Bounds())
+	raster := rasterx.NewDasher(PieceSize, PieceSize, scanner)
+
+	// Render SVG
+	icon.Draw(raster, 1.0)
+
+	// Convert to Ebiten image
+	return ebiten.NewImageFromImage(rgba), nil
+}
+
+// InitPieces loads all piece images
+func InitPieces() error {
+	PieceImages = make(map[int]*ebiten.Image)
+	pieces := map[int]string{
+		game.Pawn:   "p",
+		game.Knight: "n",
+		game.Bishop: "b",
+		game.Rook:   "r",
+		game.Queen:  "q",
+		game.King:   "k",
+	}
+
+	for pieceType, letter := range pieces {
+		// Load white piece
+		whitePath := filepath.Join("assets", "pieces", fmt.Sprintf("w%s.svg", letter))
+		whiteImg, err := loadPieceImage(whitePath)
+		if err != nil {
+			return fmt.Errorf("error loading white piece %s: %v", letter, err)
+		}
+		PieceImages[pieceType] = whiteImg
+
+		// Load black piece
+		blackPath := filepath.Join("assets", "pieces", fmt.Sprintf("b%s.svg", letter))
+		blackImg, err := loadPieceImage(blackPath)
+		if err != nil {
+			return fmt.Errorf("error loading black piece %s: %v", letter, err)
+		}
+		PieceImages[-pieceType] = blackImg
+	}
+
+	return nil
+}
+
+// InitFonts initializes the fonts used in the game
+func InitFonts() error {
+	tt, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return err
+	}
+
+	const dpi = 72
+	defaultFont, err = opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    36,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+// Package render draws a game with Ebiten and maps mouse positions to board
+// squares. It keeps the graphics dependencies out of the rules in package game.
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"chessgame/game"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	BoardSize = 480 // Makes each square 60x60
+	PieceSize = 60  // Size of piece images
+)
+
+var (
+	lightSquareColor = color.RGBA{240, 217, 181, 255}
+	darkSquareColor  = color.RGBA{181, 136, 99, 255}
+	highlightColor   = color.RGBA{130, 151, 105, 200}
+	moveColor        = color.RGBA{130, 151, 105, 120}
+	captureColor     = color.RGBA{190, 70, 60, 200}
+	threatColor      = color.RGBA{230, 140, 30, 220}  // Frames pieces the opponent attacks
+	hangingColor     = color.RGBA{220, 40, 40, 110}   // Fills pieces the opponent can win material on
+	victoryColor     = color.RGBA{255, 215, 0, 180}   // Gold color for victory animation
+	drawColor        = color.RGBA{192, 192, 192, 180} // Silver color for drawn games
+	shadeColor       = color.RGBA{0, 0, 0, 120}       // Dims the board behind the promotion picker
+	pickerColor      = color.RGBA{250, 250, 250, 255}
+	pickerBorder     = color.RGBA{60, 60, 60, 255}
+	suggestionColor  = color.RGBA{60, 120, 200, 220} // Frames the engine's suggested move in analysis mode
+)
+
+// RenderBoard draws the chess board and pieces
+func RenderBoard(screen *ebiten.Image, g *game.Game) {
+	// Draw board squares
+	squareSize := float32(BoardSize) / 8
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			x := float32(col) * squareSize
+			y := float32(row) * squareSize
+
+			// Determine square color
+			var squareColor color.Color
+			if (row+col)%2 == 0 {
+				squareColor = lightSquareColor
+			} else {
+				squareColor = darkSquareColor
+			}
+
+			// Draw square
+			vector.DrawFilledRect(screen, x, y, squareSize, squareSize, squareColor, false)
+
+			// Draw piece
+			square := viewSquare(g, game.Position{X: col, Y: row})
+			piece := g.Board[square.Y][square.X]
+			if piece != 0 {
+				drawPiece(screen, piece, x, y)
+			}
+		}
+	}
+
+	// Draw threats to the side to move's pieces
+	if g.ShowThreats {
+		drawThreats(screen, g)
+	}
+
+	// Draw selected square highlight
+	if g.SelectedPiece.Selected {
+		x, y := squareOrigin(g, game.Position{X: g.SelectedPiece.X, Y: g.SelectedPiece.Y})
+		vector.DrawFilledRect(screen, x, y, squareSize, squareSize, highlightColor, false)
+	}
+
+	// Draw valid moves, marking captures with a frame instead of a filled square
+	for _, move := range g.ValidMoves {
+		x, y := squareOrigin(g, move.To)
+		if move.IsCapture() {
+			vector.StrokeRect(screen, x+3, y+3, squareSize-6, squareSize-6, 6, captureColor, false)
+		} else {
+			vector.DrawFilledRect(screen, x, y, squareSize, squareSize, moveColor, false)
+		}
+	}
+
+	// Draw promotion picker while waiting for a choice
+	if g.PendingPromotion.Active {
+		drawPromotionPicker(screen, g)
+	}
+
+	// Draw victory animation if game is over
+	if g.State != game.Playing {
+		drawVictoryAnimation(screen, g)
+	}
+}
+
+// drawThreats marks the pieces of the side to move that the opponent
+// attacks. Pieces the opponent can capture and come out ahead, by static
+// exchange evaluation, are filled instead of framed.
+func drawThreats(screen *ebiten.Image, g *game.Game) {
+	squareSize := float32(BoardSize) / 8
+	for _, threat := range g.Threats() {
+		x, y := squareOrigin(g, threat.Square)
+		if threat.Hanging {
+			vector.DrawFilledRect(screen, x, y, squareSize, squareSize, hangingColor, false)
+		} else {
+			vector.StrokeRect(screen, x+2, y+2, squareSize-4, squareSize-4, 3, threatColor, false)
+		}
+	}
+}
+
+// drawVictoryAnimation creates a pulsing overlay with text
+func drawVictoryAnimation(screen *ebiten.Image, g *game.Game) {
+	// Calculate animation alpha based on tick
+	alpha := float64(g.AnimationTick%120) / 120.0 // Slower pulse
+	alpha = math.Sin(alpha * math.Pi * 2)
+	alpha = (alpha + 1) / 2 // Normalize to 0-1 range
+
+	// Draws get a silver overlay instead of gold
+	baseColor := victoryColor
+	if g.State == game.Draw {
+		baseColor = drawColor
+	}
+
+	// Create overlay color with animated alpha
+	overlayColor := color.RGBA{
+		R: baseColor.R,
+		G: baseColor.G,
+		B: baseColor.B,
+		A: uint8(float64(baseColor.A) * alpha),
+	}
+
+	// Draw full screen overlay
+	vector.DrawFilledRect(screen, 0, 0, float32(BoardSize), float32(BoardSize), overlayColor, false)
+
+	// Draw the reason above the result
+	var result string
+	switch g.State {
+	case game.WhiteWins:
+		result = "White Wins!"
+	case game.BlackWins:
+		result = "Black Wins!"
+	default:
+		result = "Draw!"
+	}
+
+	lineHeight := defaultFont.Metrics().Height.Ceil()
+	drawOverlayText(screen, g.Reason.String()+"!", BoardSize/2-lineHeight/2, alpha)
+	drawOverlayText(screen, result, BoardSize/2+lineHeight/2, alpha)
+}
+
+// RenderPrompt dims the board and shows a message, one line per argument,
+// in the middle of it
+func RenderPrompt(screen *ebiten.Image, lines ...string) {
+	vector.DrawFilledRect(screen, 0, 0, float32(BoardSize), float32(BoardSize), shadeColor, false)
+
+	lineHeight := defaultFont.Metrics().Height.Ceil()
+	top := BoardSize/2 - lineHeight*(len(lines)-1)/2
+	for i, line := range lines {
+		drawOverlayText(screen, line, top+i*lineHeight, 1)
+	}
+}
+
+// RenderAnalysis frames the squares of the engine's suggested move and writes
+// the move and the evaluation, from White's point of view, beside the board
+func RenderAnalysis(screen *ebiten.Image, g *game.Game, result game.SearchResult) {
+	squareSize := float32(BoardSize) / 8
+	for _, square := range []game.Position{result.Move.From, result.Move.To} {
+		x, y := squareOrigin(g, square)
+		vector.StrokeRect(screen, x+2, y+2, squareSize-4, squareSize-4, 4, suggestionColor, false)
+	}
+
+	// Scores are from the side to move's point of view
+	score, mate := result.Score, result.MateIn()
+	if !g.Turn {
+		score, mate = -score, -mate
+	}
+	var evaluation string
+	if mate != 0 {
+		evaluation = fmt.Sprintf("#%d", mate)
+	} else {
+		evaluation = fmt.Sprintf("%+.2f", float64(score)/100)
+	}
+
+	lineHeight := defaultFont.Metrics().Height.Ceil()
+	x := BoardSize + lineHeight/2
+	text.Draw(screen, "Best: "+g.SAN(result.Move), defaultFont, x, lineHeight, color.White)
+	text.Draw(screen, evaluation, defaultFont, x, 2*lineHeight, color.White)
+}
+
+// drawOverlayText draws a horizontally centered line of glowing text around centerY
+func drawOverlayText(screen *ebiten.Image, message string, centerY int, alpha float64) {
+	// Center the text
+	bounds := text.BoundString(defaultFont, message)
+	x := (BoardSize - bounds.Dx()) / 2
+	y := centerY + bounds.Dy()/2
+
+	// Draw text with glow effect
+	glowColor := color.RGBA{0, 0, 0, uint8(200 * (1 - alpha))}
+	for dx := -2; dx <= 2; dx++ {
+		for dy := -2; dy <= 2; dy++ {
+			if dx*dx+dy*dy <= 4 { // Only draw within a circular radius
+				text.Draw(screen, message, defaultFont, x+dx, y+dy, glowColor)
+			}
+		}
+	}
+
+	// Draw main text
+	text.Draw(screen, message, defaultFont, x, y, color.White)
+}
+
+// promotionPickerSquares returns the squares covered by the promotion picker,
+// starting on the promotion square and running toward the center of the board
+func promotionPickerSquares(g *game.Game) []game.Position {
+	to := g.PendingPromotion.To
+	direction := 1
+	if to.Y == 7 {
+		direction = -1
+	}
+
+	squares := make([]game.Position, len(game.PromotionPieces))
+	for i := range game.PromotionPieces {
+		squares[i] = game.Position{X: to.X, Y: to.Y + i*direction}
+	}
+	return squares
+}
+
+// drawPromotionPicker draws the queen/rook/bishop/knight choice over the promotion square
+func drawPromotionPicker(screen *ebiten.Image, g *game.Game) {
+	squareSize := float32(BoardSize) / 8
+
+	// Dim the rest of the board
+	vector.DrawFilledRect(screen, 0, 0, float32(BoardSize), float32(BoardSize), shadeColor, false)
+
+	// Promote to a piece of the moving pawn's color
+	pieceColor := game.White
+	if g.Board[g.PendingPromotion.From.Y][g.PendingPromotion.From.X] < 0 {
+		pieceColor = game.Black
+	}
+	for i, square := range promotionPickerSquares(g) {
+		x, y := squareOrigin(g, square)
+		vector.DrawFilledRect(screen, x, y, squareSize, squareSize, pickerColor, false)
+		vector.StrokeRect(screen, x, y, squareSize, squareSize, 1, pickerBorder, false)
+		drawPiece(screen, pieceColor*game.PromotionPieces[i], x, y)
+	}
+}
+
+// GetPromotionChoice returns the piece picked at the given screen coordinates,
+// or false if the coordinates are outside the promotion picker
+func GetPromotionChoice(g *game.Game, x, y int) (int, bool) {
+	if !g.PendingPromotion.Active || !IsInsideBoard(x, y) {
+		return game.Empty, false
+	}
+
+	picked := GetBoardSquare(g, x, y)
+	for i, square := range promotionPickerSquares(g) {
+		if square == picked {
+			return game.PromotionPieces[i], true
+		}
+	}
+	return game.Empty, false
+}
+
+// drawPiece draws a chess piece image
+func drawPiece(screen *ebiten.Image, piece int, x, y float32) {
+	if img, ok := PieceImages[piece]; ok {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(x), float64(y))
+		screen.DrawImage(img, op)
+	}
+}
+
+// GetBoardCoordinates converts screen coordinates to board coordinates
+func GetBoardCoordinates(x, y int) (int, int) {
+	squareSize := BoardSize / 8
+	boardX := x / squareSize
+	boardY := y / squareSize
+	return boardX, boardY
+}
+
+// GetBoardSquare converts screen coordinates to the board square under them,
+// taking the board orientation into account
+func GetBoardSquare(g *game.Game, x, y int) game.Position {
+	boardX, boardY := GetBoardCoordinates(x, y)
+	return viewSquare(g, game.Position{X: boardX, Y: boardY})
+}
+
+// viewSquare maps between board squares and on-screen squares. Flipping is its
+// own inverse, so the same mapping works in both directions.
+func viewSquare(g *game.Game, pos game.Position) game.Position {
+	if g.Flipped {
+		return game.Position{X: 7 - pos.X, Y: 7 - pos.Y}
+	}
+	return pos
+}
+
+// squareOrigin returns the screen coordinates of a board square's top-left corner
+func squareOrigin(g *game.Game, pos game.Position) (float32, float32) {
+	squareSize := float32(BoardSize) / 8
+	view := viewSquare(g, pos)
+	return float32(view.X) * squareSize, float32(view.Y) * squareSize
+}
+
+// IsInsideBoard checks if screen coordinates are within the board
+func IsInsideBoard(x, y int) bool {
+	boardX, boardY := GetBoardCoordinates(x, y)
+	return boardX >= 0 && boardX < 8 && boardY >= 0 && boardY < 8
+}